@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gorutin/internal/config"
+	"gorutin/internal/logic"
+	"gorutin/internal/sim"
+	"os"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+)
+
+// benchFixture - карта для замера: движок с фиксированным seed, прогретый warmup ходами
+type benchFixture struct {
+	name string
+	opts sim.Options
+}
+
+var benchFixtures = []benchFixture{
+	{"small", sim.Options{Width: 40, Height: 40, Units: 6, Enemies: 2, Mobs: 2, ObstacleRate: 0.25, View: 5, Seed: 1}},
+	{"default", sim.DefaultOptions()},
+	{"crowded", sim.Options{Width: 40, Height: 40, Units: 6, Enemies: 10, Mobs: 8, ObstacleRate: 0.4, View: 5, Seed: 2}},
+	{"large", sim.Options{Width: 120, Height: 120, Units: 6, Enemies: 8, Mobs: 6, ObstacleRate: 0.35, View: 5, Seed: 3}},
+}
+
+// runBench меряет CalculateTurn на фикстурах
+func runBench(name string, args []string) error {
+	only := ""
+	warmup := 60
+	cfg, err := loadConfig(name, args, config.Options{Flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&only, "fixture", "", "comma-separated fixtures to run: "+strings.Join(benchFixtureNames(), ", "))
+		fs.IntVar(&warmup, "warmup", warmup, "turns played before measuring, to fill the bot's memory")
+	}})
+	if err != nil {
+		return err
+	}
+	selected := map[string]bool{}
+	for _, n := range strings.Split(only, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			selected[n] = true
+		}
+	}
+	for n := range selected {
+		if !benchFixtureKnown(n) {
+			return fmt.Errorf("unknown fixture %q; known: %s", n, strings.Join(benchFixtureNames(), ", "))
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "FIXTURE\tMAP\tRUNS\tTIME/TURN\tBYTES/TURN\tALLOCS/TURN\t")
+	for _, f := range benchFixtures {
+		if len(selected) > 0 && !selected[f.name] {
+			continue
+		}
+		engine := sim.New(f.opts)
+		bot := newBot(cfg)
+		tracker := logic.NewCommandTracker()
+		start := time.Now()
+		bot.Clock = func() time.Time { return start.Add(engine.Elapsed()) }
+		for i := 0; i < warmup; i++ {
+			simTurn(engine, bot, tracker)
+			engine.Advance(cfg.TickInterval)
+		}
+		state := engine.State()
+		r := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bot.CalculateTurn(state)
+			}
+		})
+		fmt.Fprintf(tw, "%s\t%dx%d\t%d\t%v\t%d\t%d\t\n", f.name, f.opts.Width, f.opts.Height, r.N,
+			time.Duration(r.NsPerOp()).Round(time.Microsecond), r.AllocedBytesPerOp(), r.AllocsPerOp())
+	}
+	return tw.Flush()
+}
+
+func benchFixtureNames() []string {
+	names := make([]string, 0, len(benchFixtures))
+	for _, f := range benchFixtures {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func benchFixtureKnown(name string) bool {
+	for _, f := range benchFixtures {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
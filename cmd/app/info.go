@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"gorutin/internal/client"
+	"gorutin/internal/config"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// runRounds печатает расписание раундов с обратным отсчетом
+func runRounds(name string, args []string) error {
+	cfg, err := loadConfig(name, args, config.Options{Online: true})
+	if err != nil {
+		return err
+	}
+	api := client.NewClient(cfg.Server, cfg.Token)
+	rounds, err := api.GetRounds()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Server %s, server clock ahead by %v\n\n", cfg.Server, api.Clock.Offset().Round(time.Millisecond))
+
+	list := rounds.Rounds
+	sort.SliceStable(list, func(i, j int) bool { return list[i].StartAt < list[j].StartAt })
+	now := time.Now()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROUND\tSTATUS\tSTART (local)\tEND (local)\tDURATION\tCOUNTDOWN")
+	for _, r := range list {
+		startAt, err1 := time.Parse(time.RFC3339, r.StartAt)
+		endAt, err2 := time.Parse(time.RFC3339, r.EndAt)
+		if err1 != nil || err2 != nil {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\tbad time\n", r.Name, r.Status, r.StartAt, r.EndAt)
+			continue
+		}
+		start, end := api.Clock.ToLocal(startAt).Local(), api.Clock.ToLocal(endAt).Local()
+		var countdown string
+		switch {
+		case now.Before(start):
+			countdown = "starts in " + start.Sub(now).Round(time.Second).String()
+		case now.Before(end):
+			countdown = "ends in " + end.Sub(now).Round(time.Second).String()
+		default:
+			countdown = "ended " + now.Sub(end).Round(time.Second).String() + " ago"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%v\t%s\n", r.Name, r.Status,
+			start.Format("Jan 02 15:04:05"), end.Format("15:04:05"), end.Sub(start), countdown)
+	}
+	return tw.Flush()
+}
+
+// runBoosters печатает текущие усиления, доступные улучшения и что купил бы бот
+func runBoosters(name string, args []string) error {
+	cfg, err := loadConfig(name, args, config.Options{Online: true})
+	if err != nil {
+		return err
+	}
+	api := client.NewClient(cfg.Server, cfg.Token)
+	resp, err := api.GetAvailableBoosters()
+	if err != nil {
+		return err
+	}
+
+	s := resp.State
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Points\t%d\n", s.Points)
+	fmt.Fprintf(tw, "Speed\t%d\n", s.Speed)
+	fmt.Fprintf(tw, "Bomb range\t%d\n", s.BombRange)
+	fmt.Fprintf(tw, "Bombs\t%d\n", s.MaxBombs)
+	fmt.Fprintf(tw, "Bomb delay\t%dms\n", s.BombDelay)
+	fmt.Fprintf(tw, "View\t%d\n", s.View)
+	fmt.Fprintf(tw, "Armor\t%d\n", s.Armor)
+	fmt.Fprintf(tw, "Bombers\t%d\n", s.Bombers)
+	fmt.Fprintf(tw, "Pass bombs/obstacles/walls\t%v/%v/%v\n", s.CanPassBombs, s.CanPassObstacles, s.CanPassWalls)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	if len(resp.Available) == 0 {
+		fmt.Println("No upgrades available")
+		return nil
+	}
+	fmt.Fprintln(tw, "UPGRADE\tCOST\tAFFORDABLE")
+	for _, b := range resp.Available {
+		fmt.Fprintf(tw, "%s\t%d\t%v\n", b.Type, b.Cost, b.Cost <= s.Points)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	bot := newBot(cfg)
+	bot.UpdateBoosterState(s)
+	if kind, ok := bot.PickBooster(resp.Available, s); ok {
+		fmt.Printf("\nBot would buy: %s\n", kind)
+	} else {
+		fmt.Println("\nBot would save points")
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"gorutin/internal/client"
+	"sync"
+	"time"
+)
+
+const (
+	phaseWaiting  = "waiting"   // активного раунда нет, следующий не скоро или неизвестен
+	phasePreStart = "pre-start" // до начала следующего раунда меньше preStartLead
+	phaseActive   = "active"
+	phaseEnding   = "ending"   // до конца раунда меньше endingLead
+	phaseFinished = "finished" // раунд закончился; после опроса расписания - снова ожидание
+
+	preStartLead    = 15 * time.Second
+	endingLead      = 10 * time.Second
+	pollIdle        = 10 * time.Second // раундов в расписании нет
+	pollWaiting     = 5 * time.Second
+	pollError       = 5 * time.Second
+	recheckCooldown = time.Second // чаще по жалобам fetchLoop расписание не спрашиваем
+)
+
+// roundInfo - раунд с временем начала и конца в местных часах
+type roundInfo struct {
+	Name       string
+	Start, End time.Time
+}
+
+// transition - смена фазы жизненного цикла раунда
+type transition struct {
+	From, To string
+	Round    roundInfo
+}
+
+// roundLifecycle следит за расписанием раундов: waiting -> pre-start -> active -> ending -> finished -> waiting.
+// Хуки зовутся из его горутины на каждую смену фазы. К началу раунда просыпается ровно в startAt,
+// к концу - ровно в endAt, не дожидаясь очередного опроса расписания.
+type roundLifecycle struct {
+	api *client.DatsClient
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	phase    string
+	round    roundInfo
+	hooks    []func(transition)
+	recheck  chan struct{}
+	lastPoll time.Time
+	stopped  bool
+}
+
+func newRoundLifecycle(api *client.DatsClient) *roundLifecycle {
+	l := &roundLifecycle{api: api, phase: phaseWaiting, recheck: make(chan struct{}, 1)}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// OnTransition добавляет хук на смену фазы; регистрировать до run
+func (l *roundLifecycle) OnTransition(hook func(transition)) {
+	l.hooks = append(l.hooks, hook)
+}
+
+// Phase - текущая фаза и раунд
+func (l *roundLifecycle) Phase() (string, roundInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.phase, l.round
+}
+
+func playing(phase string) bool { return phase == phaseActive || phase == phaseEnding }
+
+// WaitActive блокируется, пока раунд не идет; false - жизненный цикл остановлен
+func (l *roundLifecycle) WaitActive(ctx context.Context) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for !playing(l.phase) && !l.stopped {
+		l.cond.Wait()
+	}
+	return !l.stopped && ctx.Err() == nil
+}
+
+// Recheck просит опросить расписание раньше срока (сервер ответил, что игры нет)
+func (l *roundLifecycle) Recheck() {
+	select {
+	case l.recheck <- struct{}{}:
+	default:
+	}
+}
+
+func (l *roundLifecycle) run(ctx context.Context) {
+	defer func() {
+		l.mu.Lock()
+		l.stopped = true
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	}()
+	for {
+		wait, wake := l.poll()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if wake != nil {
+				wake()
+			}
+		case <-l.recheck:
+			timer.Stop()
+			if d := recheckCooldown - time.Since(l.lastPoll); d > 0 && !sleep(ctx, d) {
+				return
+			}
+		}
+	}
+}
+
+// poll опрашивает расписание, переключает фазу и возвращает, сколько спать до следующего опроса.
+// wake - переход, который нужно сделать точно по истечении wait (начало или конец раунда).
+func (l *roundLifecycle) poll() (time.Duration, func()) {
+	l.lastPoll = time.Now()
+	current, next, err := l.schedule()
+	if err != nil {
+		roundLog.Warn("can't get rounds", "err", err)
+		return pollError, nil
+	}
+	now := time.Now()
+	phase, round := l.Phase()
+
+	if current != nil {
+		if playing(phase) && round.Name != current.Name {
+			l.transit(phaseFinished, round) // раунды идут встык
+		}
+		if time.Until(current.End) <= endingLead {
+			l.transit(phaseEnding, *current)
+			return time.Until(current.End), func() { l.transit(phaseFinished, *current) }
+		}
+		l.transit(phaseActive, *current)
+		untilEnding := current.End.Add(-endingLead).Sub(now)
+		if untilEnding <= roundsInterval {
+			return untilEnding, func() { l.transit(phaseEnding, *current) }
+		}
+		return roundsInterval, nil
+	}
+
+	if playing(phase) {
+		l.transit(phaseFinished, round)
+	}
+	if next == nil {
+		l.transit(phaseWaiting, roundInfo{})
+		return pollIdle, nil
+	}
+	untilStart := next.Start.Sub(now)
+	if untilStart <= preStartLead {
+		l.transit(phasePreStart, *next)
+	} else {
+		l.transit(phaseWaiting, *next)
+	}
+	if untilStart <= pollWaiting {
+		return untilStart, func() { l.transit(phaseActive, *next) }
+	}
+	if untilStart-preStartLead > 0 && untilStart-preStartLead < pollWaiting {
+		return untilStart - preStartLead, nil
+	}
+	return pollWaiting, nil
+}
+
+// schedule - идущий сейчас и ближайший следующий раунды (nil - нет такого)
+func (l *roundLifecycle) schedule() (current, next *roundInfo, err error) {
+	rounds, err := l.api.GetRounds()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	for _, r := range rounds.Rounds {
+		startAt, err := time.Parse(time.RFC3339, r.StartAt)
+		if err != nil {
+			roundLog.Warn("bad startAt", "round", r.Name, "start_at", r.StartAt, "err", err)
+			continue
+		}
+		endAt, err := time.Parse(time.RFC3339, r.EndAt)
+		if err != nil {
+			roundLog.Warn("bad endAt", "round", r.Name, "end_at", r.EndAt, "err", err)
+			continue
+		}
+		info := roundInfo{Name: r.Name, Start: l.api.Clock.ToLocal(startAt), End: l.api.Clock.ToLocal(endAt)}
+		if now.After(info.End) {
+			continue
+		}
+		// Статус сервера отстает от расписания на время опроса - смотрим и на время
+		if r.Status == "active" || !now.Before(info.Start) {
+			current = &info
+			continue
+		}
+		if next == nil || info.Start.Before(next.Start) {
+			next = &info
+		}
+	}
+	return current, next, nil
+}
+
+// transit переключает фазу и зовет хуки; повтор той же фазы того же раунда ничего не делает
+func (l *roundLifecycle) transit(to string, round roundInfo) {
+	l.mu.Lock()
+	if l.phase == to && l.round.Name == round.Name {
+		l.round = round // время могло уточниться
+		l.mu.Unlock()
+		return
+	}
+	t := transition{From: l.phase, To: to, Round: round}
+	l.phase, l.round = to, round
+	l.cond.Broadcast()
+	l.mu.Unlock()
+
+	switch {
+	case round.Name == "":
+		roundLog.Info("no rounds scheduled", "from", t.From, "to", t.To)
+	case to == phaseWaiting || to == phasePreStart:
+		roundLog.Info("round "+to, "from", t.From, "to", t.To, "round", round.Name,
+			"starts_in", time.Until(round.Start).Round(time.Second), "start", round.Start.Format("15:04:05"))
+	default:
+		roundLog.Info("round "+to, "from", t.From, "to", t.To, "round", round.Name,
+			"ends_in", time.Until(round.End).Round(time.Second), "end", round.End.Format("15:04:05"))
+	}
+	for _, hook := range l.hooks {
+		hook(t)
+	}
+}
@@ -2,187 +2,108 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
-	"gorutin/internal/client"
-	"gorutin/internal/domain"
+	"gorutin/internal/config"
+	"gorutin/internal/logging"
 	"gorutin/internal/logic"
-	"gorutin/internal/viz"
-	"log"
 	"os"
 	"strings"
-	"time"
 )
 
-// loadEnv простая функция для чтения .env файла без сторонних библиотек
-func loadEnv() {
-	data, err := os.ReadFile(".env")
-	if err != nil {
-		return // Файла нет, не страшно
-	}
+// Логгеры компонентов; уровни - log_level в конфиге
+var (
+	appLog      = logging.For(logging.App)
+	roundLog    = logging.For(logging.Round)
+	pipelineLog = logging.For(logging.Pipeline)
+	unitLog     = logging.For(logging.Unit)
+	safetyLog   = logging.For(logging.Safety)
+	boostsLog   = logging.For(logging.Boosts)
+	stateLog    = logging.For(logging.State)
+	recordLog   = logging.For(logging.Record)
+	apiLog      = logging.For(logging.API)
+	simLog      = logging.For(logging.Sim)
+)
 
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			value = strings.Trim(value, `"'`)
-			os.Setenv(key, value)
-		}
-	}
-}
+// closeLog закрывает файл лога, открытый loadConfig
+var closeLog = func() error { return nil }
 
-func main() {
-	loadEnv()
+// command - подкоманда бинарника
+type command struct {
+	name, args, summary string
+	run                 func(name string, args []string) error
+}
 
+var commands = []command{
+	{"play", "", "play live rounds on the server (default)", runPlay},
+	{"replay", "<recording or dir>", "run the bot against recorded rounds", runReplay},
+	{"sim", "", "play against the local engine", runSim},
+	{"rounds", "", "show the round schedule with countdowns", runRounds},
+	{"boosters", "", "show booster state and available upgrades", runBoosters},
+	{"bench", "", "benchmark planning on fixture maps", runBench},
+}
 
-token := os.Getenv("TOKEN")
-	if token == "" {
-		log.Println("CRITICAL: TOKEN env var is not set!")
-		log.Println("Run: $env:TOKEN='your_token' or create .env file")
+func main() {
+	args := os.Args[1:]
+	name := "play"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-	serverURL := "https://games-test.datsteam.dev"
-
-	log.Printf("Starting bot on %s...", serverURL)
-
-	api := client.NewClient(serverURL, token)
-	bot := logic.NewBot()
-
-	// Запускаем сервер визуализации
-	vizServer := viz.NewServer()
-	vizServer.Start(":8080")
-	log.Println("Visualization started on http://localhost:8080")
-
-	ticker := time.NewTicker(650 * time.Millisecond)
-	defer ticker.Stop()
-	
-	lastBoosterLog := time.Time{}
-	var currentBoosters *domain.BoosterState
-
-	for range ticker.C {
-		// 1. Пытаемся получить состояние
-		state, err := api.GetGameState()
-		
-		if err != nil {
-			var serverErr *domain.ServerError
-			if errors.As(err, &serverErr) {
-				if serverErr.ErrCode == 23 {
-					checkRoundsSchedule(api, ticker)
-					continue
-				}
-				if serverErr.ErrCode == 1 {
-					log.Fatal("ERROR: Invalid or missing TOKEN.")
-				}
-			}
-			log.Printf("API Error: %v", err)
+	if name == "help" {
+		usage()
+		return
+	}
+	for _, c := range commands {
+		if c.name != name {
 			continue
 		}
-
-		// 2. Раз в 10 секунд обновляем бустеры и покупаем новые
-		if time.Since(lastBoosterLog) > 5*time.Second {
-			lastBoosterLog = time.Now()
-			boosters, err := api.GetAvailableBoosters()
-			if err == nil {
-				currentBoosters = &boosters.State
-				
-				// Обновляем статы бота (чтобы он знал про свой радиус)
-				bot.UpdateBoosterState(boosters.State)
-
-				// Логируем
-				s := boosters.State
-				log.Printf("[BOOSTS] Points: %d | Speed: %d | Range: %d | Bombs: %d/%d", 
-					s.Points, s.Speed, s.BombRange, s.MaxBombs, s.Bombers)
-
-				// Покупаем
-				if boosterID, ok := logic.ChooseBooster(boosters.Available, boosters.State, state); ok {
-					if err := api.ActivateBooster(boosterID); err != nil {
-						log.Printf("Error activating booster: %v", err)
-					} else {
-						log.Printf("BOUGHT BOOSTER ID=%d!", boosterID)
-					}
-				}
-			}
+		err := c.run(os.Args[0]+" "+name, args)
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
-
-		// 3. Логика игры
-		log.Printf("[%s] Units: %d | Enemies: %d | Score: %d", 
-			state.Round, len(state.MyUnits), len(state.Enemies), state.RawScore)
-
-		playerCmd := bot.CalculateTurn(state)
-
-		// Обновляем данные для браузера
-		vizServer.Update(state, bot.GetGrid(), currentBoosters)
-
-		if playerCmd != nil && len(playerCmd.Bombers) > 0 {
-			var logParts []string
-			for _, b := range playerCmd.Bombers {
-				idShort := b.ID
-				if len(idShort) > 4 {
-					idShort = idShort[len(idShort)-4:]
-				}
-				logParts = append(logParts, fmt.Sprintf("U:%s(P:%d,B:%d)", idShort, len(b.Path), len(b.Bombs)))
-			}
-			vizServer.AddLog(fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), strings.Join(logParts, " | ")))
-
-			if err := api.SendCommands(*playerCmd); err != nil {
-				log.Printf("Error sending commands: %v", err)
-			}
+		if err != nil {
+			// Ошибки конфига многострочные - печатаем как есть, а не полем лога
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			closeLog()
+			os.Exit(1)
 		}
+		closeLog()
+		return
 	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
 }
 
-func checkRoundsSchedule(api *client.DatsClient, ticker *time.Ticker) {
-	rounds, err := api.GetRounds()
-	if err != nil {
-		log.Printf("No active game. Waiting... (Error getting rounds: %v)", err)
-		ticker.Reset(5 * time.Second)
-		return
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %-20s %s\n", c.name, c.args, c.summary)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for flags. Settings come from bot.yaml, environment and flags.\n", os.Args[0])
+}
 
-	var activeRound *domain.RoundResponse
-	var nextRound *domain.RoundResponse
-	now := time.Now().UTC()
-
-	for i := range rounds.Rounds {
-		r := &rounds.Rounds[i]
-		startAt, _ := time.Parse(time.RFC3339, r.StartAt)
-
-		if r.Status == "active" {
-			activeRound = r
-			break
-		}
-		
-		if startAt.After(now) {
-			if nextRound == nil {
-				nextRound = r
-			} else {
-				nextStart, _ := time.Parse(time.RFC3339, nextRound.StartAt)
-				if startAt.Before(nextStart) {
-					nextRound = r
-				}
-			}
-		}
+// loadConfig - конфиг команды; ошибки конфига объясняют, что поправить. Заодно настраивает логи.
+func loadConfig(name string, args []string, opts config.Options) (*config.Config, error) {
+	cfg, err := config.Load(name, args, opts)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return nil, fmt.Errorf("config error:\n%w", err)
 	}
-
-	if activeRound != nil {
-		log.Printf("Round '%s' is ACTIVE! Connecting...", activeRound.Name)
-		ticker.Reset(100 * time.Millisecond) // Сразу пробуем подключиться
-	} else if nextRound != nil {
-		startAt, _ := time.Parse(time.RFC3339, nextRound.StartAt)
-		wait := time.Until(startAt)
-		log.Printf("No active round. Next round '%s' starts in %v (%s)", nextRound.Name, wait.Round(time.Second), startAt.Format("15:04:05 UTC"))
-		
-		if wait > 10*time.Second {
-			ticker.Reset(5 * time.Second)
-		} else {
-			ticker.Reset(1 * time.Second)
-		}
-	} else {
-		log.Println("No active game and no future rounds found. Waiting...")
-		ticker.Reset(10 * time.Second)
+	if err != nil {
+		return nil, err
 	}
-}
\ No newline at end of file
+	closeFile, err := logging.Setup(cfg.LogLevel, cfg.LogFile)
+	if err != nil {
+		return nil, fmt.Errorf("log file: %w", err)
+	}
+	closeLog = closeFile
+	return cfg, nil
+}
+
+// newBot - бот с настройками из конфига
+func newBot(cfg *config.Config) *logic.Bot {
+	bot := logic.NewBot()
+	bot.TickInterval = cfg.TickInterval
+	bot.Strategy = logic.Strategies[cfg.Strategy]
+	return bot
+}
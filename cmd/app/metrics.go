@@ -0,0 +1,99 @@
+package main
+
+import (
+	"gorutin/internal/client"
+	"gorutin/internal/domain"
+	"gorutin/internal/logic"
+	"gorutin/internal/metrics"
+	"time"
+)
+
+// botMetrics - метрики живой игры для /metrics. Счетчики копятся за всю сессию, через раунды:
+// Prometheus сам посчитает rate и increase.
+type botMetrics struct {
+	reg *metrics.Registry
+
+	apiLatency *metrics.Histogram // endpoint
+	apiErrors  *metrics.Counter   // endpoint, code
+	planTime   *metrics.Histogram
+	aliveUnits *metrics.Gauge
+	score      *metrics.Gauge
+	bombs      *metrics.Counter
+	obstacles  *metrics.Counter
+	deaths     *metrics.Counter
+
+	prev *domain.GameState // прошлый снимок - для смертей и разрушенных препятствий
+}
+
+func newBotMetrics(api *client.DatsClient) *botMetrics {
+	reg := metrics.NewRegistry()
+	m := &botMetrics{
+		reg:        reg,
+		apiLatency: reg.Histogram("bot_api_request_duration_seconds", "API request latency until response headers, by endpoint.", metrics.DefaultBuckets, "endpoint"),
+		apiErrors:  reg.Counter("bot_api_errors_total", "API errors by server errCode (http_<status> if unparsed, network for transport errors).", "endpoint", "code"),
+		planTime:   reg.Histogram("bot_plan_duration_seconds", "Planning time per tick.", metrics.DefaultBuckets),
+		aliveUnits: reg.Gauge("bot_alive_units", "Alive units on the latest snapshot."),
+		score:      reg.Gauge("bot_score", "Round score on the latest snapshot.", "round"),
+		bombs:      reg.Counter("bot_bombs_placed_total", "Bombs in commands accepted by the server."),
+		obstacles:  reg.Counter("bot_obstacles_destroyed_total", "Obstacles that vanished from cells our units see."),
+		deaths:     reg.Counter("bot_deaths_total", "Our units that died."),
+	}
+	reg.CounterFunc("bot_rate_limit_throttled_total", "Requests held back by the client rate limiter.",
+		func() float64 { return float64(api.Limiter.Throttled()) })
+	return m
+}
+
+func (m *botMetrics) ObserveRequest(endpoint string, took time.Duration) {
+	m.apiLatency.Observe(took.Seconds(), endpoint)
+}
+
+func (m *botMetrics) ObserveError(endpoint, code string) {
+	m.apiErrors.Inc(endpoint, code)
+}
+
+// observeTurn - снимок, по которому бот только что спланировал ход. Зовется из горутины планирования.
+func (m *botMetrics) observeTurn(state *domain.GameState, bot *logic.Bot, plan time.Duration) {
+	m.planTime.Observe(plan.Seconds())
+	alive := 0
+	for _, u := range state.MyUnits {
+		if u.Alive {
+			alive++
+		}
+	}
+	m.aliveUnits.Set(float64(alive))
+	m.score.Set(float64(state.RawScore), state.Round)
+
+	prev := m.prev
+	m.prev = state
+	if prev == nil || prev.Round != state.Round {
+		return
+	}
+	wasAlive := map[string]bool{}
+	for _, u := range prev.MyUnits {
+		wasAlive[u.ID] = u.Alive
+	}
+	for _, u := range state.MyUnits {
+		if wasAlive[u.ID] && !u.Alive {
+			m.deaths.Inc()
+		}
+	}
+	// Препятствие пропало из клетки, которую мы видим сейчас, - значит, взорвано, а не ушло в туман
+	current := make(map[domain.Vec2d]bool, len(state.Arena.Obstacles))
+	for _, o := range state.Arena.Obstacles {
+		current[o] = true
+	}
+	for _, o := range prev.Arena.Obstacles {
+		if !current[o] && bot.SeenNow(o) {
+			m.obstacles.Inc()
+		}
+	}
+}
+
+// observeSent - команды, которые сервер принял
+func (m *botMetrics) observeSent(cmd domain.PlayerCommand) {
+	bombs := 0
+	for _, u := range cmd.Bombers {
+		bombs += len(u.Bombs)
+	}
+	m.bombs.Add(float64(bombs))
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorutin/internal/client"
+	"gorutin/internal/domain"
+	"gorutin/internal/logic"
+	"gorutin/internal/record"
+	"gorutin/internal/viz"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minFetchInterval = 250 * time.Millisecond  // чаще арену не просим: лимит общий, остальное решит RateLimiter
+	maxCommandAge    = 1500 * time.Millisecond // команды по более старому снимку уже не актуальны
+	roundsInterval   = 30 * time.Second        // как часто уточняем расписание во время раунда
+	statsLogInterval = 10 * time.Second
+	latencySmoothing = 0.2              // вес нового замера в скользящем среднем
+	stateSaveEvery   = 10 * time.Second // снимок памяти бота на случай падения
+	shutdownTimeout  = 5 * time.Second  // сколько ждем запросы в полете при остановке
+)
+
+// snapshot - состояние арены и когда оно получено
+type snapshot struct {
+	seq       int
+	state     *domain.GameState
+	fetchedAt time.Time
+}
+
+// outgoing - команды, рассчитанные по снимку seq
+type outgoing struct {
+	seq       int
+	cmd       domain.PlayerCommand
+	fetchedAt time.Time
+}
+
+// pipeline - конвейер тика: получение арены, планирование и отправка команд идут в своих горутинах.
+// Следующий снимок запрашивается, пока команды по предыдущему еще летят; между стадиями каналы
+// на один элемент, и новый снимок (или команда) вытесняет не успевший обработаться старый.
+// Бот трогает только горутина планирования - бустеры и смены фазы раунда приходят к ней через каналы.
+type pipeline struct {
+	api       *client.DatsClient
+	bot       *logic.Bot
+	registry  *logic.BoosterRegistry
+	tracker   *logic.CommandTracker
+	vizServer *viz.Server
+	lifecycle *roundLifecycle
+	recorder  *record.Recorder
+	metrics   *botMetrics
+
+	boosterInterval time.Duration
+	stateFile       string // куда сохранять память бота ("" - не сохранять)
+
+	cancel   context.CancelCauseFunc
+	inflight sync.WaitGroup // запросы и горутины, которые надо дождаться при остановке
+
+	snapshots   chan snapshot
+	commands    chan outgoing
+	boosters    chan *domain.AvailableBoosterResponse
+	transitions chan transition // не вытесняются: пропустить смену раунда нельзя
+
+	stats pipelineStats
+}
+
+func newPipeline(api *client.DatsClient, bot *logic.Bot, registry *logic.BoosterRegistry, tracker *logic.CommandTracker, vizServer *viz.Server, recorder *record.Recorder, metrics *botMetrics, boosterInterval time.Duration, stateFile string) *pipeline {
+	return &pipeline{
+		api:             api,
+		bot:             bot,
+		registry:        registry,
+		tracker:         tracker,
+		vizServer:       vizServer,
+		lifecycle:       newRoundLifecycle(api),
+		recorder:        recorder,
+		metrics:         metrics,
+		boosterInterval: boosterInterval,
+		stateFile:       stateFile,
+		snapshots:       make(chan snapshot, 1),
+		commands:        make(chan outgoing, 1),
+		boosters:        make(chan *domain.AvailableBoosterResponse, 1),
+		transitions:     make(chan transition, 8),
+	}
+}
+
+// run крутит конвейер до отмены ctx (сигнал) или фатальной ошибки, затем останавливается аккуратно:
+// дожидается запросов в полете, сохраняет память бота и дописывает запись раунда.
+// Возвращает фатальную ошибку (nil - остановлен сигналом).
+func (p *pipeline) run(ctx context.Context) error {
+	ctx, p.cancel = context.WithCancelCause(ctx)
+	p.lifecycle.OnTransition(func(t transition) { p.onTransition(ctx, t) })
+	go p.lifecycle.run(ctx)
+	p.goInflight(func() { p.fetchLoop(ctx) })
+	p.goInflight(func() { p.sendLoop(ctx) })
+	p.goInflight(func() { p.maintenanceLoop(ctx) })
+	round := p.planLoop(ctx)
+
+	appLog.Info("shutting down, waiting for requests in flight", "cause", context.Cause(ctx))
+	done := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		appLog.Warn("requests still in flight, not waiting", "timeout", shutdownTimeout)
+	}
+	p.saveState(round)
+	p.closeRecording()
+	pipelineLog.Info("stats", "stats", p.report())
+
+	if err := context.Cause(ctx); !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+func (p *pipeline) goInflight(f func()) {
+	p.inflight.Add(1)
+	go func() {
+		defer p.inflight.Done()
+		f()
+	}()
+}
+
+// saveState сохраняет память бота для перезапуска в том же раунде
+func (p *pipeline) saveState(round string) {
+	if p.stateFile == "" || round == "" {
+		return
+	}
+	if err := logic.SaveSnapshot(p.stateFile, p.bot.Snapshot(round)); err != nil {
+		stateLog.Error("can't save bot state", "file", p.stateFile, "err", err)
+	}
+}
+
+// restoreState - поднять память бота, сохраненную в этом же раунде (после падения или перезапуска)
+func (p *pipeline) restoreState(round roundInfo) {
+	if p.stateFile == "" {
+		return
+	}
+	snap, err := logic.LoadSnapshot(p.stateFile)
+	if err != nil {
+		stateLog.Error("can't load bot state", "file", p.stateFile, "err", err)
+		return
+	}
+	if snap == nil || snap.Round != round.Name || snap.SavedAt.Before(round.Start) || snap.SavedAt.After(round.End) {
+		return // состояние другого раунда - начинаем с чистого листа
+	}
+	p.bot.Restore(snap)
+	stateLog.Info("restored bot state", "round", snap.Round, "age", time.Since(snap.SavedAt).Round(time.Second),
+		"tick", snap.Tick, "memory_targets", len(snap.MemoryTargets), "units", len(snap.Units))
+}
+
+// closeRecording дописывает запись раунда, если она идет
+func (p *pipeline) closeRecording() {
+	if path, frames, err := p.recorder.Close(); err != nil {
+		recordLog.Error("can't finish recording", "err", err)
+	} else if path != "" {
+		recordLog.Info("recording saved", "path", path, "frames", frames)
+	}
+}
+
+// sleep - пауза, прерываемая остановкой; false - пора выходить
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// onTransition - хук жизненного цикла раунда. Трекер команд сбрасываем сразу (он под мьютексом),
+// бота - в горутине планирования.
+func (p *pipeline) onTransition(ctx context.Context, t transition) {
+	p.vizServer.AddLog(fmt.Sprintf("[%s] round %s: %s -> %s", time.Now().Format("15:04:05"), t.Round.Name, t.From, t.To))
+	switch t.To {
+	case phaseActive:
+		if t.From != phaseEnding {
+			p.tracker.Reset()
+			p.stats.reset()
+			if err := p.recorder.Start(t.Round.Name); err != nil {
+				recordLog.Error("can't start recording", "round", t.Round.Name, "err", err)
+			}
+		}
+	case phaseFinished:
+		pipelineLog.Info("round finished", "round", t.Round.Name, "stats", p.report())
+		p.tracker.Reset()
+		p.closeRecording()
+	}
+	select {
+	case p.transitions <- t:
+	case <-ctx.Done():
+	}
+}
+
+// fetchLoop запрашивает арену так часто, как позволяет лимит, пока идет раунд
+func (p *pipeline) fetchLoop(ctx context.Context) {
+	seq := 0
+	for p.lifecycle.WaitActive(ctx) {
+		start := time.Now()
+		state, err := p.api.GetGameState()
+		if err != nil {
+			var serverErr *domain.ServerError
+			if errors.As(err, &serverErr) {
+				if serverErr.ErrCode == 23 {
+					// Игры нет: раунд кончился раньше расписания или еще не начался на сервере
+					p.lifecycle.Recheck()
+					sleep(ctx, minFetchInterval)
+					continue
+				}
+				if serverErr.ErrCode == 1 {
+					p.cancel(errors.New("invalid or missing TOKEN"))
+					return
+				}
+			}
+			apiLog.Warn("can't get arena", "err", err)
+			sleep(ctx, minFetchInterval)
+			continue
+		}
+		seq++
+		p.stats.observe(&p.stats.fetch, time.Since(start))
+		if offer(p.snapshots, snapshot{seq: seq, state: state, fetchedAt: time.Now()}) {
+			p.stats.count(&p.stats.droppedSnapshots)
+		}
+		if wait := minFetchInterval - time.Since(start); wait > 0 {
+			sleep(ctx, wait)
+		}
+	}
+}
+
+// planLoop считает ходы по самому свежему снимку и передает команды на отправку.
+// Возвращает имя идущего раунда, когда ctx отменен.
+func (p *pipeline) planLoop(ctx context.Context) string {
+	var currentBoosters *domain.BoosterState
+	lastPlan := time.Time{}
+	roundStarted := time.Time{}
+	lastStatsLog := time.Now()
+	lastSave := time.Now()
+	round := ""
+	// Покупка - три запроса через лимитер; пока она идет, новую не начинаем: очки в свежем снимке еще старые
+	buying := make(chan struct{}, 1)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return round
+
+		case boosters := <-p.boosters:
+			currentBoosters = &boosters.State
+			// Обновляем статы бота (чтобы он знал про свой радиус)
+			p.bot.UpdateBoosterState(boosters.State)
+
+			s := boosters.State
+			boostsLog.Info("booster state", "points", s.Points, "speed", s.Speed, "range", s.BombRange,
+				"bombs", s.MaxBombs, "bombers", s.Bombers)
+
+			// Покупаем по плану на остаток раунда и сверяем, что купилось
+			if len(buying) > 0 {
+				boostsLog.Debug("purchase in progress, skipping booster pick")
+			} else if kind, ok := p.bot.PickBooster(boosters.Available, boosters.State); ok {
+				buying <- struct{}{}
+				p.goInflight(func() {
+					defer func() { <-buying }()
+					buyBooster(p.api, p.registry, p.vizServer, boosters.Available, kind)
+				})
+			}
+
+		case t := <-p.transitions:
+			switch t.To {
+			case phaseActive:
+				if t.From != phaseEnding {
+					// По doc.md с началом раунда прогресс сбрасывается - память бота и план бустеров тоже.
+					// Если же мы перезапустились посреди раунда, поднимаем сохраненное.
+					p.bot.Reset()
+					p.registry.ForgetFailed()
+					p.restoreState(t.Round)
+					currentBoosters = nil
+					lastPlan = time.Time{}
+					roundStarted = time.Now()
+					round = t.Round.Name
+				}
+				p.bot.SetRoundWindow(t.Round.Start, t.Round.End)
+			case phaseEnding:
+				p.bot.SetRoundWindow(t.Round.Start, t.Round.End)
+			case phaseFinished:
+				round = ""
+			}
+
+		case snap := <-p.snapshots:
+			if snap.fetchedAt.Before(roundStarted) {
+				continue // снимок прошлого раунда
+			}
+			start := time.Now()
+			if !lastPlan.IsZero() {
+				// Тик бота - реальный интервал между снимками, а не номинальные 650мс
+				p.bot.TickInterval = p.stats.observe(&p.stats.interval, start.Sub(lastPlan))
+			}
+			lastPlan = start
+			p.plan(snap, currentBoosters)
+			p.stats.observe(&p.stats.plan, time.Since(start))
+
+			if time.Since(lastStatsLog) > statsLogInterval {
+				lastStatsLog = time.Now()
+				pipelineLog.Info("stats", "stats", p.report())
+			}
+			// Процесс могут убить и без сигнала - сохраняемся и по ходу раунда
+			if time.Since(lastSave) > stateSaveEvery {
+				lastSave = time.Now()
+				p.saveState(round)
+			}
+		}
+	}
+}
+
+func (p *pipeline) plan(snap snapshot, currentBoosters *domain.BoosterState) {
+	// Планируем на момент, когда команды дойдут до сервера, а не на момент снимка
+	lead := p.stats.observe(&p.stats.lead, p.api.Clock.Lead(snap.fetchedAt))
+	state := snap.state
+
+	start := time.Now()
+	t := planTurn(p.bot, p.tracker, state, lead, currentBoosters)
+	p.metrics.observeTurn(state, p.bot, time.Since(start))
+	pipelineLog.Info("tick", "round", state.Round, "tick", p.bot.Tick, "units", len(state.MyUnits),
+		"enemies", len(state.Enemies), "score", state.RawScore)
+	for _, ff := range t.friendlyFire {
+		safetyLog.Warn("bomb dropped", "round", state.Round, "tick", p.bot.Tick, "unit", ff.Bomber,
+			"victim", ff.Victim, "bomb", ff.Bomb, "reason", ff.Reason)
+		p.vizServer.AddLog(fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), ff))
+	}
+	for _, v := range t.violations {
+		safetyLog.Warn("command violates rules", "round", state.Round, "tick", p.bot.Tick, "unit", v.UnitID,
+			"rule", v.Rule, "detail", v.Detail, "dropped", v.Dropped)
+	}
+	// В запись идет снимок как есть, а рядом сдвиг и усиления: replay спроецирует его так же
+	frame := record.Frame{At: snap.fetchedAt, State: snap.state, Command: t.cmd, Lead: lead, Boosters: currentBoosters}
+	if err := p.recorder.Record(frame); err != nil {
+		recordLog.Error("can't record frame", "err", err)
+	}
+
+	// Обновляем данные для браузера
+	p.vizServer.Update(state, p.bot.GetGrid(), currentBoosters)
+	p.vizServer.SetOverlay("enemies", p.bot.EnemyOverlay())
+	p.vizServer.SetOverlay("chain", p.bot.ChainOverlay())
+	p.vizServer.SetOverlay("respawn", p.bot.RespawnOverlay())
+	p.vizServer.SetOverlay("boosterPlan", p.bot.BoosterPlan)
+	p.vizServer.SetOverlay("units", p.bot.UnitsOverlay())
+	p.vizServer.SetOverlay("debug", p.bot.DebugOverlay())
+	p.vizServer.SetOverlay("commands", t.cmd)
+	p.vizServer.SetOverlay("pipeline", p.report())
+	p.vizServer.RecordTick(p.bot.Tick, t.cmd, p.bot.Decisions)
+
+	if t.cmd == nil {
+		return
+	}
+	var logParts []string
+	for _, b := range t.cmd.Bombers {
+		idShort := b.ID
+		if len(idShort) > 4 {
+			idShort = idShort[len(idShort)-4:]
+		}
+		logParts = append(logParts, fmt.Sprintf("U:%s(P:%d,B:%d)", idShort, len(b.Path), len(b.Bombs)))
+	}
+	p.vizServer.AddLog(fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), strings.Join(logParts, " | ")))
+
+	if offer(p.commands, outgoing{seq: snap.seq, cmd: *t.cmd, fetchedAt: snap.fetchedAt}) {
+		p.stats.count(&p.stats.droppedCommands)
+	}
+}
+
+// sendLoop отправляет команды; устаревшие (по слишком старому снимку) выбрасывает
+func (p *pipeline) sendLoop(ctx context.Context) {
+	for {
+		var out outgoing
+		select {
+		case <-ctx.Done():
+			return
+		case out = <-p.commands:
+		}
+		if time.Since(out.fetchedAt) > maxCommandAge {
+			p.stats.count(&p.stats.droppedCommands)
+			continue
+		}
+		start := time.Now()
+		if err := p.api.SendCommands(out.cmd); err != nil {
+			apiLog.Warn("can't send commands", "seq", out.seq, "units", len(out.cmd.Bombers), "err", err)
+		} else {
+			p.tracker.Accepted(out.cmd)
+			p.metrics.observeSent(out.cmd)
+		}
+		p.stats.observe(&p.stats.send, time.Since(start))
+		p.stats.observe(&p.stats.age, time.Since(out.fetchedAt))
+	}
+}
+
+// maintenanceLoop раз в несколько секунд узнает бустеры, пока идет раунд
+func (p *pipeline) maintenanceLoop(ctx context.Context) {
+	for p.lifecycle.WaitActive(ctx) {
+		if boosters, err := p.api.GetAvailableBoosters(); err == nil {
+			offer(p.boosters, boosters)
+		}
+		if !sleep(ctx, p.boosterInterval) {
+			return
+		}
+	}
+}
+
+// PipelineStats - задержки стадий конвейера (скользящее среднее, мс) и счетчики
+type PipelineStats struct {
+	FetchMs          float64 `json:"fetch_ms"`
+	PlanMs           float64 `json:"plan_ms"`
+	SendMs           float64 `json:"send_ms"`
+	AgeMs            float64 `json:"age_ms"` // возраст снимка к моменту отправки команд
+	IntervalMs       float64 `json:"interval_ms"`
+	LatencyMs        float64 `json:"latency_ms"` // задержка до сервера в одну сторону
+	OffsetMs         float64 `json:"offset_ms"`  // насколько часы сервера впереди наших
+	LeadMs           float64 `json:"lead_ms"`    // на сколько вперед проецируется снимок
+	DroppedSnapshots int     `json:"dropped_snapshots"`
+	DroppedCommands  int     `json:"dropped_commands"`
+	Throttled        int     `json:"throttled"`
+	Sent             int     `json:"sent"`
+	Skipped          int     `json:"skipped"`
+}
+
+func (s PipelineStats) String() string {
+	return fmt.Sprintf("fetch %.0fms | plan %.0fms | send %.0fms | age %.0fms | tick %.0fms | latency %.0fms offset %.0fms lead %.0fms | dropped %d/%d | throttled %d | sent %d skipped %d",
+		s.FetchMs, s.PlanMs, s.SendMs, s.AgeMs, s.IntervalMs, s.LatencyMs, s.OffsetMs, s.LeadMs, s.DroppedSnapshots, s.DroppedCommands, s.Throttled, s.Sent, s.Skipped)
+}
+
+type pipelineStats struct {
+	mu                                sync.Mutex
+	fetch, plan, send, age, interval  time.Duration
+	lead                              time.Duration
+	droppedSnapshots, droppedCommands int
+}
+
+// observe добавляет замер в скользящее среднее и возвращает его
+func (s *pipelineStats) observe(avg *time.Duration, d time.Duration) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if *avg == 0 {
+		*avg = d
+	} else {
+		*avg = time.Duration(float64(*avg)*(1-latencySmoothing) + float64(d)*latencySmoothing)
+	}
+	return *avg
+}
+
+// reset обнуляет замеры (новый раунд)
+func (s *pipelineStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetch, s.plan, s.send, s.age, s.interval, s.lead = 0, 0, 0, 0, 0, 0
+	s.droppedSnapshots, s.droppedCommands = 0, 0
+}
+
+func (s *pipelineStats) count(counter *int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*counter++
+}
+
+func (p *pipeline) report() PipelineStats {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	sent, skipped, _ := p.tracker.Counts()
+	s := &p.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return PipelineStats{
+		FetchMs:          ms(s.fetch),
+		PlanMs:           ms(s.plan),
+		SendMs:           ms(s.send),
+		AgeMs:            ms(s.age),
+		IntervalMs:       ms(s.interval),
+		LatencyMs:        ms(p.api.Clock.OneWay()),
+		OffsetMs:         ms(p.api.Clock.Offset()),
+		LeadMs:           ms(s.lead),
+		DroppedSnapshots: s.droppedSnapshots,
+		DroppedCommands:  s.droppedCommands,
+		Throttled:        p.api.Limiter.Throttled(),
+		Sent:             sent,
+		Skipped:          skipped,
+	}
+}
+
+// offer кладет v в канал на один элемент, вытесняя необработанное старое значение.
+// true - старое значение выброшено.
+func offer[T any](ch chan T, v T) bool {
+	dropped := false
+	for {
+		select {
+		case ch <- v:
+			return dropped
+		default:
+		}
+		select {
+		case <-ch:
+			dropped = true
+		default:
+		}
+	}
+}
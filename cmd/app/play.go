@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorutin/internal/client"
+	"gorutin/internal/config"
+	"gorutin/internal/domain"
+	"gorutin/internal/logic"
+	"gorutin/internal/record"
+	"gorutin/internal/viz"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runPlay - живая игра на сервере
+func runPlay(name string, args []string) error {
+	cfg, err := loadConfig(name, args, config.Options{Online: true})
+	if err != nil {
+		return err
+	}
+	if len(cfg.Args) > 0 {
+		return fmt.Errorf("unexpected arguments: %v", cfg.Args)
+	}
+	appLog.Info("starting bot", "config", cfg)
+
+	api := client.NewClient(cfg.Server, cfg.Token)
+	bot := newBot(cfg)
+	registry, err := logic.NewBoosterRegistry(cfg.BoosterIDs, cfg.Server)
+	if err != nil {
+		boostsLog.Error("can't load learned booster IDs, learning from scratch", "file", cfg.BoosterIDs, "err", err)
+	}
+	tracker := logic.NewCommandTracker()
+	recorder := record.NewRecorder(cfg.RecordDir)
+	metrics := newBotMetrics(api)
+	api.Observer = metrics
+
+	// Запускаем сервер визуализации (и метрики на /metrics)
+	vizServer := viz.NewServer()
+	vizServer.Metrics = metrics.reg.Handler()
+	vizServer.History = cfg.VizHistory
+	vizServer.Start(cfg.VizAddr)
+	appLog.Info("visualization started", "addr", cfg.VizAddr, "metrics", "/metrics")
+
+	// Первый сигнал - аккуратная остановка, второй (после stop) - немедленный выход
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	defer stop()
+	return newPipeline(api, bot, registry, tracker, vizServer, recorder, metrics, cfg.BoosterInterval, cfg.StateFile).run(ctx)
+}
+
+// buyBooster подбирает ID для kind, покупает и проверяет по BoosterState, что купилось именно оно
+func buyBooster(api *client.DatsClient, registry *logic.BoosterRegistry, vizServer *viz.Server, available []domain.Booster, kind string) {
+	boosterID, ok := registry.Resolve(available, kind)
+	if !ok {
+		boostsLog.Warn("no booster ID left to try", "kind", kind)
+		return
+	}
+	before, after, err := api.ActivateAndConfirm(boosterID)
+	if err != nil {
+		// Ни отказ сервера (нет очков, 429), ни неподтвержденная покупка ничего не говорят об ID - не учимся
+		if errors.Is(err, client.ErrNotConfirmed) {
+			boostsLog.Warn("booster bought but not confirmed", "kind", kind, "id", boosterID, "err", err)
+		} else {
+			boostsLog.Error("can't activate booster", "kind", kind, "id", boosterID, "err", err)
+		}
+		return
+	}
+	outcome, err := registry.Record(kind, boosterID, before, after)
+	if err != nil {
+		boostsLog.Error("can't save learned booster IDs", "err", err)
+	}
+	if outcome.Wrong() {
+		boostsLog.Warn("wrong booster bought", "wanted", outcome.Wanted, "got", outcome.Got, "id", outcome.ID, "outcome", outcome)
+	} else {
+		boostsLog.Info("booster bought", "wanted", outcome.Wanted, "got", outcome.Got, "id", outcome.ID, "outcome", outcome)
+	}
+	if outcome.Wrong() {
+		vizServer.AddLog(fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), outcome))
+	}
+}
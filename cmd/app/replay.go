@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"gorutin/internal/config"
+	"gorutin/internal/domain"
+	"gorutin/internal/logic"
+	"gorutin/internal/record"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// replayStats - итоги прогона бота по записи
+type replayStats struct {
+	frames     int
+	commands   int // снимков, по которым бот что-то отправил бы
+	units      int // команд юнитам
+	diverged   int // команд юнитам, которые расходятся с записанными
+	violations int
+	ff         int
+	plan       time.Duration
+	maxPlan    time.Duration
+	score      int // очки на последнем снимке
+}
+
+func (s *replayStats) add(o replayStats) {
+	s.frames += o.frames
+	s.commands += o.commands
+	s.units += o.units
+	s.diverged += o.diverged
+	s.violations += o.violations
+	s.ff += o.ff
+	s.plan += o.plan
+	s.maxPlan = max(s.maxPlan, o.maxPlan)
+	s.score += o.score
+}
+
+func (s replayStats) String() string {
+	avg := time.Duration(0)
+	if s.frames > 0 {
+		avg = s.plan / time.Duration(s.frames)
+	}
+	return fmt.Sprintf("frames %d | commands %d (units %d, diverged %d) | violations %d | friendly fire %d | plan avg %v max %v | score %d",
+		s.frames, s.commands, s.units, s.diverged, s.violations, s.ff,
+		avg.Round(time.Microsecond), s.maxPlan.Round(time.Microsecond), s.score)
+}
+
+// runReplay прогоняет бота по записанным раундам и сравнивает его команды с записанными
+func runReplay(name string, args []string) error {
+	cfg, err := loadConfig(name, args, config.Options{})
+	if err != nil {
+		return err
+	}
+	if len(cfg.Args) != 1 {
+		return fmt.Errorf("usage: %s [flags] <recording%s or directory>", name, record.Ext)
+	}
+	files, err := record.Files(cfg.Args[0])
+	if err != nil {
+		return err
+	}
+
+	var total replayStats
+	for _, path := range files {
+		s, err := replayFile(cfg, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Printf("%s: %s\n", filepath.Base(path), s)
+		total.add(s)
+	}
+	if len(files) > 1 {
+		fmt.Printf("total: %s\n", total)
+	}
+	return nil
+}
+
+func replayFile(cfg *config.Config, path string) (replayStats, error) {
+	r, err := record.Open(path)
+	if err != nil {
+		return replayStats{}, err
+	}
+	defer r.Close()
+
+	bot := newBot(cfg)
+	tracker := logic.NewCommandTracker()
+	var s replayStats
+	var prev time.Time
+	for {
+		f, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return s, nil
+		}
+		if err != nil {
+			return s, err
+		}
+		if f.State == nil {
+			continue
+		}
+		// Бот живет в записанном времени: тик и часы - как были в раунде
+		at := f.At
+		bot.Clock = func() time.Time { return at }
+		if !prev.IsZero() && f.At.After(prev) {
+			bot.TickInterval = f.At.Sub(prev)
+		}
+		prev = f.At
+
+		// Как в живой игре: сначала усиления, потом проекция снимка на момент доставки команд
+		if f.Boosters != nil {
+			bot.UpdateBoosterState(*f.Boosters)
+		}
+
+		start := time.Now()
+		t := planTurn(bot, tracker, f.State, f.Lead, f.Boosters)
+		d := time.Since(start)
+
+		s.frames++
+		s.plan += d
+		s.maxPlan = max(s.maxPlan, d)
+		s.violations += len(t.violations)
+		s.ff += len(t.friendlyFire)
+		s.score = f.State.RawScore
+		s.diverged += divergedUnits(t.cmd, f.Command)
+		if t.cmd != nil {
+			// Сервера нет - считаем, что он принял все, что прошло проверку
+			tracker.Accepted(*t.cmd)
+			s.commands++
+			s.units += len(t.cmd.Bombers)
+		}
+	}
+}
+
+// divergedUnits - скольким юнитам replay дал не ту команду, что в записи
+func divergedUnits(got, want *domain.PlayerCommand) int {
+	byID := func(c *domain.PlayerCommand) map[string]domain.UnitCommand {
+		m := map[string]domain.UnitCommand{}
+		if c != nil {
+			for _, u := range c.Bombers {
+				m[u.ID] = u
+			}
+		}
+		return m
+	}
+	g, w := byID(got), byID(want)
+	n := 0
+	for id, gc := range g {
+		if wc, ok := w[id]; !ok || !sameCommand(gc, wc) {
+			n++
+		}
+	}
+	for id := range w {
+		if _, ok := g[id]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+func sameCommand(a, b domain.UnitCommand) bool {
+	if len(a.Path) != len(b.Path) || len(a.Bombs) != len(b.Bombs) {
+		return false
+	}
+	for i := range a.Path {
+		if a.Path[i] != b.Path[i] {
+			return false
+		}
+	}
+	for i := range a.Bombs {
+		if a.Bombs[i] != b.Bombs[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gorutin/internal/config"
+	"gorutin/internal/logic"
+	"gorutin/internal/sim"
+	"gorutin/internal/viz"
+	"time"
+)
+
+const simLogInterval = 30 * time.Second // симулированного времени
+
+// runSim играет против локального движка: быстрее реального времени или, с -watch, в реальном и с визуализацией
+func runSim(name string, args []string) error {
+	opts := sim.DefaultOptions()
+	duration := 5 * time.Minute
+	watch := false
+	cfg, err := loadConfig(name, args, config.Options{Flags: func(fs *flag.FlagSet) {
+		simFlags(fs, &opts)
+		fs.DurationVar(&duration, "duration", duration, "simulated round length")
+		fs.BoolVar(&watch, "watch", false, "run in real time and show the game in the visualization")
+	}})
+	if err != nil {
+		return err
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	engine := sim.New(opts)
+	bot := newBot(cfg)
+	tracker := logic.NewCommandTracker()
+	start := time.Now()
+	bot.Clock = func() time.Time { return start.Add(engine.Elapsed()) }
+	bot.SetRoundWindow(start, start.Add(duration))
+
+	var vizServer *viz.Server
+	if watch {
+		vizServer = viz.NewServer()
+		vizServer.History = cfg.VizHistory
+		vizServer.Start(cfg.VizAddr)
+		appLog.Info("visualization started", "addr", cfg.VizAddr)
+	}
+
+	simLog.Info("simulation started", "map", fmt.Sprintf("%dx%d", opts.Width, opts.Height), "seed", opts.Seed,
+		"enemies", opts.Enemies, "mobs", opts.Mobs, "strategy", cfg.Strategy, "duration", duration)
+	nextLog := simLogInterval
+	for engine.Elapsed() < duration {
+		t, errs := simTurn(engine, bot, tracker)
+		for _, err := range errs {
+			simLog.Warn("command rejected", "elapsed", engine.Elapsed(), "err", err)
+		}
+		if watch {
+			vizServer.Update(bot.State, bot.GetGrid(), nil)
+			vizServer.SetOverlay("units", bot.UnitsOverlay())
+			vizServer.SetOverlay("chain", bot.ChainOverlay())
+			vizServer.SetOverlay("debug", bot.DebugOverlay())
+			vizServer.SetOverlay("commands", t.cmd)
+			vizServer.RecordTick(bot.Tick, t.cmd, bot.Decisions)
+			for _, ff := range t.friendlyFire {
+				vizServer.AddLog(ff.String())
+			}
+			time.Sleep(cfg.TickInterval)
+		}
+		engine.Advance(cfg.TickInterval)
+		if engine.Elapsed() >= nextLog {
+			nextLog += simLogInterval
+			simLog.Info("progress", "elapsed", engine.Elapsed().Round(time.Second), "stats", engine.Stats())
+		}
+	}
+	fmt.Printf("%s (real %v)\n", engine.Stats(), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// simFlags - флаги карты симулятора
+func simFlags(fs *flag.FlagSet, opts *sim.Options) {
+	fs.Int64Var(&opts.Seed, "seed", opts.Seed, "map seed")
+	fs.IntVar(&opts.Width, "width", opts.Width, "map width")
+	fs.IntVar(&opts.Height, "height", opts.Height, "map height")
+	fs.IntVar(&opts.Units, "units", opts.Units, "our units")
+	fs.IntVar(&opts.Enemies, "enemies", opts.Enemies, "wandering enemy units")
+	fs.IntVar(&opts.Mobs, "mobs", opts.Mobs, "patrol mobs")
+	fs.Float64Var(&opts.ObstacleRate, "obstacles", opts.ObstacleRate, "share of free cells with obstacles")
+}
+
+// simTurn - один ход бота в симуляторе; сервер здесь - движок, он же принимает команды
+func simTurn(engine *sim.Engine, bot *logic.Bot, tracker *logic.CommandTracker) (turn, []error) {
+	t := planTurn(bot, tracker, engine.State(), 0, nil)
+	if t.cmd == nil {
+		return t, nil
+	}
+	accepted, errs := engine.Apply(*t.cmd)
+	tracker.Accepted(accepted)
+	return t, errs
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"gorutin/internal/domain"
+	"gorutin/internal/logic"
+	"log/slog"
+	"time"
+)
+
+// turn - итог планирования одного снимка
+type turn struct {
+	cmd          *domain.PlayerCommand // nil - слать нечего
+	violations   []domain.Violation
+	friendlyFire []logic.FriendlyFire
+}
+
+// planTurn - общий для play, replay и sim путь от снимка к командам: сверка принятых путей,
+// проекция на lead вперед, ход бота, отсев повторов и проверка по правилам сервера.
+// Трекер сверяется с настоящим снимком, а проекция идет от настоящего места юнита на его пути.
+func planTurn(bot *logic.Bot, tracker *logic.CommandTracker, snapshot *domain.GameState, lead time.Duration, boosters *domain.BoosterState) turn {
+	tracker.Observe(snapshot)
+	state := logic.ProjectState(snapshot, lead, bot.Speed, tracker.InFlight)
+
+	t := turn{cmd: bot.CalculateTurn(state)}
+	t.friendlyFire = append([]logic.FriendlyFire(nil), bot.FriendlyFire...)
+	traceDecisions(bot, state.Round)
+
+	// Пока юнит идет по принятому пути, повторять ту же команду незачем
+	t.cmd = tracker.Filter(t.cmd, bot.PathHazard)
+
+	// Сервер молча отбрасывает невалидные команды - чиним или выкидываем их сами
+	if t.cmd != nil {
+		validated, violations := domain.NewCommandValidator(state, boosters).Validate(*t.cmd)
+		t.violations = violations
+		t.cmd = &validated
+		if len(validated.Bombers) == 0 {
+			t.cmd = nil
+		}
+	}
+	return t
+}
+
+// traceDecisions пишет на debug, почему каждый юнит получил свою команду: фаза решения, цель, ее оценка и путь
+func traceDecisions(bot *logic.Bot, round string) {
+	if !unitLog.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	for _, d := range bot.Decisions {
+		attrs := []any{"round", round, "tick", bot.Tick, "unit", d.Unit, "phase", d.Phase}
+		if d.Detail != "" {
+			attrs = append(attrs, "detail", d.Detail)
+		}
+		if d.Target != nil {
+			attrs = append(attrs, "target", *d.Target)
+		}
+		if d.Score != 0 {
+			attrs = append(attrs, "score", d.Score)
+		}
+		attrs = append(attrs, "path", d.Path, "bombs", d.Bombs)
+		unitLog.Debug("decision", attrs...)
+	}
+}
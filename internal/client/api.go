@@ -3,17 +3,29 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gorutin/internal/domain"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// Observer получает замеры запросов - для метрик. endpoint - "GET /api/arena" и т.п.
+type Observer interface {
+	ObserveRequest(endpoint string, took time.Duration)
+	// ObserveError: code - errCode ответа сервера, "http_<статус>", если ответ не разобрать, или "network"
+	ObserveError(endpoint, code string)
+}
+
 type DatsClient struct {
-	BaseURL string
-	Token   string
-	Client  *http.Client
+	BaseURL  string
+	Token    string
+	Client   *http.Client
+	Limiter  *RateLimiter
+	Clock    *ClockSync
+	Observer Observer // nil - замеры не нужны
 }
 
 func NewClient(url, token string) *DatsClient {
@@ -23,9 +35,37 @@ func NewClient(url, token string) *DatsClient {
 		Client: &http.Client{
 			Timeout: 2 * time.Second,
 		},
+		Limiter: NewRateLimiter(requestsPerSecond, rateWindow),
+		Clock:   NewClockSync(),
 	}
 }
 
+// do отправляет запрос, дождавшись места в лимите запросов
+func (c *DatsClient) do(req *http.Request, priority int) (*http.Response, error) {
+	resp, _, err := c.timedDo(req, priority)
+	return resp, err
+}
+
+// timedDo - do, который еще возвращает момент отправки (для синхронизации часов)
+func (c *DatsClient) timedDo(req *http.Request, priority int) (*http.Response, time.Time, error) {
+	c.Limiter.Wait(priority)
+	sent := time.Now()
+	resp, err := c.Client.Do(req)
+	took := time.Since(sent)
+	if err == nil {
+		c.Clock.ObserveRTT(took)
+	}
+	if c.Observer != nil {
+		c.Observer.ObserveRequest(endpoint(req), took)
+		if err != nil {
+			c.Observer.ObserveError(endpoint(req), "network")
+		}
+	}
+	return resp, sent, err
+}
+
+func endpoint(req *http.Request) string { return req.Method + " " + req.URL.Path }
+
 func (c *DatsClient) checkError(resp *http.Response) error {
 	if resp.StatusCode == 200 {
 		return nil
@@ -33,13 +73,21 @@ func (c *DatsClient) checkError(resp *http.Response) error {
 
 	var apiErr domain.ServerError
 	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil {
+		c.observeError(resp, strconv.Itoa(apiErr.ErrCode))
 		return &apiErr // Возвращаем типизированную ошибку
 	}
 
 	// Если не удалось распарсить JSON ошибки
+	c.observeError(resp, "http_"+strconv.Itoa(resp.StatusCode))
 	return fmt.Errorf("http status %s", resp.Status)
 }
 
+func (c *DatsClient) observeError(resp *http.Response, code string) {
+	if c.Observer != nil && resp.Request != nil {
+		c.Observer.ObserveError(endpoint(resp.Request), code)
+	}
+}
+
 func (c *DatsClient) GetGameState() (*domain.GameState, error) {
 	req, err := http.NewRequest("GET", c.BaseURL+"/api/arena", nil)
 	if err != nil {
@@ -47,7 +95,7 @@ func (c *DatsClient) GetGameState() (*domain.GameState, error) {
 	}
 	req.Header.Set("X-Auth-Token", c.Token)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, PriorityBackground)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +126,7 @@ func (c *DatsClient) SendCommands(cmd domain.PlayerCommand) error {
 	req.Header.Set("X-Auth-Token", c.Token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, PriorityCommand)
 	if err != nil {
 		return err
 	}
@@ -94,10 +142,11 @@ func (c *DatsClient) GetRounds() (*domain.RoundListResponse, error) {
 	}
 	req.Header.Set("X-Auth-Token", c.Token)
 
-	resp, err := c.Client.Do(req)
+	resp, sent, err := c.timedDo(req, PriorityBackground)
 	if err != nil {
 		return nil, err
 	}
+	received := time.Now()
 	defer resp.Body.Close()
 
 	if err := c.checkError(resp); err != nil {
@@ -108,6 +157,9 @@ func (c *DatsClient) GetRounds() (*domain.RoundListResponse, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&rounds); err != nil {
 		return nil, err
 	}
+	if now, err := time.Parse(time.RFC3339Nano, rounds.Now); err == nil {
+		c.Clock.ObserveServerTime(sent, received, now)
+	}
 
 	return &rounds, nil
 }
@@ -120,14 +172,14 @@ func (c *DatsClient) GetAvailableBoosters() (*domain.AvailableBoosterResponse, e
 	}
 	req.Header.Set("X-Auth-Token", c.Token)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, PriorityBackground)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	if err := c.checkError(resp); err != nil {
+		return nil, err
 	}
 
 	var payload domain.AvailableBoosterResponse
@@ -152,15 +204,35 @@ func (c *DatsClient) ActivateBooster(boosterID int) error {
 	req.Header.Set("X-Auth-Token", c.Token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, PriorityBackground)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("bad status: %s", resp.Status)
+	return c.checkError(resp)
+}
+
+// ErrNotConfirmed - покупка прошла, но состояние после нее получить не удалось: что купилось, неизвестно
+var ErrNotConfirmed = errors.New("booster purchase not confirmed")
+
+// ActivateAndConfirm покупает усиление и возвращает состояние до и после покупки,
+// чтобы вызывающий мог проверить, что купилось именно то, что хотели.
+// Ошибка GET после покупки оборачивается в ErrNotConfirmed.
+func (c *DatsClient) ActivateAndConfirm(boosterID int) (before, after domain.BoosterState, err error) {
+	prev, err := c.GetAvailableBoosters()
+	if err != nil {
+		return before, after, err
 	}
+	before = prev.State
 
-	return nil
+	if err := c.ActivateBooster(boosterID); err != nil {
+		return before, before, err
+	}
+
+	next, err := c.GetAvailableBoosters()
+	if err != nil {
+		return before, before, fmt.Errorf("%w: %w", ErrNotConfirmed, err)
+	}
+	return before, next.State, nil
 }
\ No newline at end of file
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	clockSamples     = 8                     // по скольким последним замерам now выбираем лучший
+	rttSmoothing     = 0.2                   // вес нового замера RTT в скользящем среднем
+	serverTickPeriod = 50 * time.Millisecond // дискретизация мира на сервере (doc.md)
+)
+
+type clockSample struct {
+	rtt    time.Duration
+	offset time.Duration
+}
+
+// ClockSync оценивает расхождение часов с сервером и задержку в одну сторону.
+// Смещение считаем как в NTP: серверное now соответствует середине запроса;
+// из последних замеров берем тот, у которого RTT меньше всего - он точнее.
+type ClockSync struct {
+	mu      sync.Mutex
+	samples []clockSample
+	offset  time.Duration // серверное время минус местное
+	rtt     time.Duration // скользящее среднее по всем запросам
+}
+
+func NewClockSync() *ClockSync { return &ClockSync{} }
+
+// ObserveRTT учитывает время ответа любого запроса
+func (c *ClockSync) ObserveRTT(rtt time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rtt == 0 {
+		c.rtt = rtt
+		return
+	}
+	c.rtt = time.Duration(float64(c.rtt)*(1-rttSmoothing) + float64(rtt)*rttSmoothing)
+}
+
+// ObserveServerTime учитывает серверное время server, полученное запросом, отправленным в sent и вернувшимся в received
+func (c *ClockSync) ObserveServerTime(sent, received, server time.Time) {
+	rtt := received.Sub(sent)
+	sample := clockSample{rtt: rtt, offset: server.Sub(sent.Add(rtt / 2))}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples = append(c.samples, sample)
+	if len(c.samples) > clockSamples {
+		c.samples = c.samples[len(c.samples)-clockSamples:]
+	}
+	best := c.samples[0]
+	for _, s := range c.samples[1:] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+	c.offset = best.offset
+}
+
+// Offset - насколько часы сервера впереди наших
+func (c *ClockSync) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// ServerNow - текущее время на сервере по нашей оценке
+func (c *ClockSync) ServerNow() time.Time { return time.Now().Add(c.Offset()).UTC() }
+
+// ToLocal переводит серверное время в местное
+func (c *ClockSync) ToLocal(server time.Time) time.Time { return server.Add(-c.Offset()) }
+
+// OneWay - оценка задержки в одну сторону
+func (c *ClockSync) OneWay() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rtt / 2
+}
+
+// StateAge - насколько устарел снимок, полученный в receivedAt: сколько он лежит у нас,
+// плюс путь от сервера и в среднем половина серверного тика
+func (c *ClockSync) StateAge(receivedAt time.Time) time.Duration {
+	return time.Since(receivedAt) + c.OneWay() + serverTickPeriod/2
+}
+
+// Lead - на сколько вперед планировать: к моменту, когда команда дойдет до сервера,
+// мир уйдет на возраст снимка плюс еще одну задержку в одну сторону
+func (c *ClockSync) Lead(receivedAt time.Time) time.Duration {
+	return c.StateAge(receivedAt) + c.OneWay()
+}
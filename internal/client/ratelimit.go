@@ -0,0 +1,70 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// Лимит API - 3 запроса в секунду на команду (doc.md); окно берем с запасом на расхождение часов
+const (
+	requestsPerSecond = 3
+	rateWindow        = time.Second + 50*time.Millisecond
+)
+
+// Приоритеты запросов: фоновые вызовы оставляют место под отправку команд
+const (
+	PriorityCommand    = 0 // /api/move - не ждет ради других
+	PriorityBackground = 1 // арена, бустеры, раунды - оставляют один запрос в окне под команды
+)
+
+// RateLimiter - скользящее окно: не больше limit запросов за window
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	sent      []time.Time
+	throttled int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window}
+}
+
+// Wait блокирует, пока запрос с приоритетом reserve не уложится в лимит.
+// reserve - сколько мест в окне оставить более важным запросам.
+func (l *RateLimiter) Wait(reserve int) {
+	if reserve >= l.limit {
+		reserve = l.limit - 1
+	}
+	allowed := l.limit - reserve
+	waited := false
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		cut := 0
+		for cut < len(l.sent) && now.Sub(l.sent[cut]) >= l.window {
+			cut++
+		}
+		l.sent = l.sent[cut:]
+		if len(l.sent) < allowed {
+			l.sent = append(l.sent, now)
+			if waited {
+				l.throttled++
+			}
+			l.mu.Unlock()
+			return
+		}
+		// Ждем, пока из окна выйдет столько старых запросов, чтобы освободилось место
+		wait := l.window - now.Sub(l.sent[len(l.sent)-allowed])
+		l.mu.Unlock()
+		waited = true
+		time.Sleep(wait)
+	}
+}
+
+// Throttled - сколько запросов пришлось придержать из-за лимита
+func (l *RateLimiter) Throttled() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.throttled
+}
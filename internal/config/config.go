@@ -0,0 +1,344 @@
+// Package config собирает настройки бота из нескольких источников.
+// Приоритет (каждый следующий перекрывает предыдущий): значения по умолчанию, профиль сервера,
+// файл конфига (JSON или YAML), переменные окружения (и .env), флаги командной строки.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gorutin/internal/logging"
+	"gorutin/internal/logic"
+	"gorutin/internal/viz"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Profile - предустановки для сервера
+type Profile struct {
+	Server string
+}
+
+// Profiles: тестовый сервер работает все дни, на основном идут финальные раунды (doc.md)
+var Profiles = map[string]Profile{
+	"test":  {Server: "https://games-test.datsteam.dev"},
+	"final": {Server: "https://games.datsteam.dev"},
+}
+
+const (
+	DefaultProfile = "test"
+
+	minTickInterval    = 50 * time.Millisecond // тик сервера
+	maxTickInterval    = 5 * time.Second
+	minBoosterInterval = time.Second // чаще - только зря тратить лимит запросов
+	maxVizHistory      = 10000       // тик - это снимок карты с сеткой, десятки КБ
+)
+
+// Config - итоговые настройки бота
+type Config struct {
+	Profile         string
+	Server          string
+	Token           string
+	VizAddr         string
+	VizHistory      int           // сколько тиков визуализация хранит для перемотки
+	TickInterval    time.Duration // начальная оценка тика; дальше бот меряет сам
+	BoosterInterval time.Duration
+	Strategy        string
+	BoosterIDs      string // файл с выученными ID бустеров
+	RecordDir       string // куда писать записи раундов ("" - не писать)
+	StateFile       string // память бота для перезапуска посреди раунда ("" - не сохранять)
+	LogLevel        string // "info,unit=debug": уровень по умолчанию и уровни компонентов
+	LogFile         string // JSON-лог ("" - только консоль)
+
+	File    string            // откуда прочитан конфиг ("" - файла нет)
+	Args    []string          // позиционные аргументы команды
+	online  bool              // команде нужен сервер (а значит, и токен)
+	sources map[string]string // ключ -> откуда взято значение
+}
+
+// Options - что нужно от конфига конкретной команде
+type Options struct {
+	Online bool                   // команда ходит на сервер: нужен токен
+	Flags  func(fs *flag.FlagSet) // собственные флаги команды
+}
+
+// option - ключ настройки: имя в файле, переменная окружения и флаг
+type option struct {
+	key, env, flag, usage string
+	set                   func(c *Config, v string) error
+}
+
+var options = []option{
+	{"profile", "BOT_PROFILE", "profile", "server profile: " + strings.Join(profileNames(), ", "), setString(func(c *Config) *string { return &c.Profile })},
+	{"server", "BOT_SERVER", "server", "server URL (overrides the profile)", setString(func(c *Config) *string { return &c.Server })},
+	{"token", "TOKEN", "token", "auth token", setString(func(c *Config) *string { return &c.Token })},
+	{"viz_addr", "BOT_VIZ_ADDR", "viz", "visualization listen address", setString(func(c *Config) *string { return &c.VizAddr })},
+	{"viz_history", "BOT_VIZ_HISTORY", "viz-history", "ticks the visualization keeps for rewinding (0 disables)", setInt(func(c *Config) *int { return &c.VizHistory })},
+	{"tick_interval", "BOT_TICK_INTERVAL", "tick", "initial tick estimate (650ms or 650)", setDuration(func(c *Config) *time.Duration { return &c.TickInterval })},
+	{"booster_interval", "BOT_BOOSTER_INTERVAL", "booster-interval", "how often to poll boosters", setDuration(func(c *Config) *time.Duration { return &c.BoosterInterval })},
+	{"strategy", "BOT_STRATEGY", "strategy", "strategy: " + strings.Join(logic.StrategyNames(), ", "), setString(func(c *Config) *string { return &c.Strategy })},
+	{"booster_ids", "BOT_BOOSTER_IDS", "booster-ids", "file with learned booster IDs", setString(func(c *Config) *string { return &c.BoosterIDs })},
+	{"record_dir", "BOT_RECORD_DIR", "record", "directory for round recordings (empty disables)", setString(func(c *Config) *string { return &c.RecordDir })},
+	{"state_file", "BOT_STATE_FILE", "state", "file for the bot's memory, restored on restart within the same round (empty disables)", setString(func(c *Config) *string { return &c.StateFile })},
+	{"log_level", "BOT_LOG_LEVEL", "log-level", "log levels: default and per component, e.g. info,unit=debug; components: " + strings.Join(logging.Components, ", "), setString(func(c *Config) *string { return &c.LogLevel })},
+	{"log_file", "BOT_LOG_FILE", "log-file", "JSON log file, appended (empty logs to the console only)", setString(func(c *Config) *string { return &c.LogFile })},
+}
+
+func setString(field func(c *Config) *string) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// setDuration понимает и "650ms", и просто число миллисекунд
+func setDuration(field func(c *Config) *time.Duration) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		if ms, err := strconv.Atoi(v); err == nil {
+			*field(c) = time.Duration(ms) * time.Millisecond
+			return nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration (use 650ms, 5s or milliseconds)", v)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func defaults() *Config {
+	return &Config{
+		Profile:         DefaultProfile,
+		VizAddr:         ":8080",
+		VizHistory:      viz.DefaultHistory,
+		TickInterval:    650 * time.Millisecond,
+		BoosterInterval: 5 * time.Second,
+		Strategy:        logic.DefaultStrategy,
+		BoosterIDs:      "booster_ids.json",
+		RecordDir:       "recordings",
+		StateFile:       "bot_state.json",
+		LogLevel:        "info",
+		sources:         map[string]string{},
+	}
+}
+
+// Load читает .env, файл конфига, окружение и флаги args (без имени программы) и проверяет результат
+func Load(name string, args []string, opts Options) (*Config, error) {
+	if err := LoadDotEnv(".env"); err != nil {
+		return nil, err
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("BOT_CONFIG"), "config file (JSON or YAML); default bot.yaml, bot.yml or bot.json if present")
+	flagValues := make(map[string]*string, len(options))
+	for _, o := range options {
+		flagValues[o.key] = fs.String(o.flag, "", o.usage)
+	}
+	if opts.Flags != nil {
+		opts.Flags(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Источники по возрастанию приоритета
+	type layer struct {
+		name   string
+		values map[string]string
+	}
+	layers := []layer{}
+
+	file, values, err := readConfigFile(*path)
+	if err != nil {
+		return nil, err
+	}
+	if file != "" {
+		layers = append(layers, layer{file, values})
+	}
+	env := map[string]string{}
+	for _, o := range options {
+		if v, ok := os.LookupEnv(o.env); ok && v != "" {
+			env[o.key] = v
+		}
+	}
+	layers = append(layers, layer{"env", env})
+	flags := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		for _, o := range options {
+			if o.flag == f.Name {
+				flags[o.key] = *flagValues[o.key]
+			}
+		}
+	})
+	layers = append(layers, layer{"flag", flags})
+
+	c := defaults()
+	c.File, c.Args, c.online = file, fs.Args(), opts.Online
+	// Профиль берем из самого приоритетного источника, и его значения идут сразу после умолчаний
+	for _, l := range layers {
+		if p, ok := l.values["profile"]; ok {
+			c.Profile, c.sources["profile"] = p, l.name
+		}
+	}
+	if p, ok := Profiles[c.Profile]; ok {
+		c.Server, c.sources["server"] = p.Server, "profile "+c.Profile
+	}
+
+	var errs []error
+	for _, l := range layers {
+		for _, o := range options {
+			v, ok := l.values[o.key]
+			if !ok || o.key == "profile" {
+				continue
+			}
+			if err := o.set(c, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s (%s): %w", o.key, l.name, err))
+				continue
+			}
+			c.sources[o.key] = l.name
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return c, c.Validate()
+}
+
+// Validate проверяет значения и объясняет, что не так и как исправить
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		where := ""
+		if src := c.sources[key]; src != "" {
+			where = " (from " + src + ")"
+		}
+		errs = append(errs, fmt.Errorf("%s%s: %s", key, where, fmt.Sprintf(format, args...)))
+	}
+
+	if _, ok := Profiles[c.Profile]; !ok {
+		fail("profile", "unknown profile %q%s; known: %s", c.Profile, suggest(c.Profile, profileNames()), strings.Join(profileNames(), ", "))
+	} else if u, err := url.Parse(c.Server); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fail("server", "%q is not an http(s) URL", c.Server)
+	}
+	if c.Token == "" && c.online {
+		fail("token", "not set; put TOKEN=... into .env, export TOKEN, add token to the config file or pass -token")
+	}
+	if _, _, err := net.SplitHostPort(c.VizAddr); err != nil {
+		fail("viz_addr", "%q is not a listen address like :8080 (%v)", c.VizAddr, err)
+	}
+	if c.VizHistory < 0 || c.VizHistory > maxVizHistory {
+		fail("viz_history", "%d is out of range 0..%d", c.VizHistory, maxVizHistory)
+	}
+	if c.TickInterval < minTickInterval || c.TickInterval > maxTickInterval {
+		fail("tick_interval", "%v is out of range %v..%v", c.TickInterval, minTickInterval, maxTickInterval)
+	}
+	if c.BoosterInterval < minBoosterInterval {
+		fail("booster_interval", "%v is too often: boosters share the 3 requests/s limit with commands, use at least %v", c.BoosterInterval, minBoosterInterval)
+	}
+	if _, ok := logic.Strategies[c.Strategy]; !ok {
+		fail("strategy", "unknown strategy %q%s; known: %s", c.Strategy, suggest(c.Strategy, logic.StrategyNames()), strings.Join(logic.StrategyNames(), ", "))
+	}
+	if c.BoosterIDs == "" {
+		fail("booster_ids", "empty file name")
+	}
+	if levels, err := logging.ParseLevels(c.LogLevel); err != nil {
+		fail("log_level", "%v", err)
+	} else {
+		for _, name := range levels.UnknownComponents() {
+			fail("log_level", "unknown component %q%s; known: %s", name, suggest(name, logging.Components), strings.Join(logging.Components, ", "))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String - настройки для лога, токен скрыт
+func (c *Config) String() string {
+	token := "<unset>"
+	if n := len(c.Token); n > 4 {
+		token = "..." + c.Token[n-4:]
+	} else if n > 0 {
+		token = "***"
+	}
+	parts := []string{}
+	add := func(key string, v any) {
+		s := fmt.Sprintf("%s=%v", key, v)
+		if src := c.sources[key]; src != "" {
+			s += " [" + src + "]"
+		}
+		parts = append(parts, s)
+	}
+	add("profile", c.Profile)
+	add("server", c.Server)
+	add("token", token)
+	add("viz_addr", c.VizAddr)
+	add("viz_history", c.VizHistory)
+	add("tick_interval", c.TickInterval)
+	add("booster_interval", c.BoosterInterval)
+	add("strategy", c.Strategy)
+	add("booster_ids", c.BoosterIDs)
+	add("record_dir", c.RecordDir)
+	add("state_file", c.StateFile)
+	add("log_level", c.LogLevel)
+	add("log_file", c.LogFile)
+	return strings.Join(parts, " ")
+}
+
+func profileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// suggest - подсказка "did you mean" для опечатки (до двух правок)
+func suggest(s string, known []string) string {
+	best, bestDist := "", 3
+	for _, k := range known {
+		if d := editDistance(strings.ToLower(s), k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// loadIn запускает Load в пустом каталоге: без .env и bot.yaml рядом и без BOT_* из окружения теста
+func loadIn(t *testing.T, files map[string]string, env map[string]string, args ...string) (*Config, error) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Setenv("BOT_CONFIG", "")
+	for _, o := range options {
+		t.Setenv(o.env, "")
+		os.Unsetenv(o.env)
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	return Load("test", args, Options{})
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	const (
+		testServer  = "https://games-test.datsteam.dev"
+		finalServer = "https://games.datsteam.dev"
+	)
+	tests := []struct {
+		name       string
+		files      map[string]string
+		env        map[string]string
+		args       []string
+		wantServer string
+		wantSource string
+		wantTick   time.Duration
+	}{
+		{
+			name:       "defaults",
+			wantServer: testServer, wantSource: "profile test", wantTick: 650 * time.Millisecond,
+		},
+		{
+			name:       "profile from env",
+			env:        map[string]string{"BOT_PROFILE": "final"},
+			wantServer: finalServer, wantSource: "profile final", wantTick: 650 * time.Millisecond,
+		},
+		{
+			name:       "profile from file, flag wins",
+			files:      map[string]string{"bot.yaml": "profile: test\n"},
+			args:       []string{"-profile", "final"},
+			wantServer: finalServer, wantSource: "profile final", wantTick: 650 * time.Millisecond,
+		},
+		{
+			name:       "file over profile",
+			files:      map[string]string{"bot.yaml": "profile: final\nserver: http://file\ntick_interval: 700ms\n"},
+			wantServer: "http://file", wantSource: "bot.yaml", wantTick: 700 * time.Millisecond,
+		},
+		{
+			name:       "env over file",
+			files:      map[string]string{"bot.yaml": "server: http://file\ntick_interval: 700ms\n"},
+			env:        map[string]string{"BOT_SERVER": "http://env"},
+			wantServer: "http://env", wantSource: "env", wantTick: 700 * time.Millisecond,
+		},
+		{
+			name:       ".env counts as env",
+			files:      map[string]string{"bot.yaml": "server: http://file\n", ".env": "BOT_SERVER=http://dotenv\n"},
+			wantServer: "http://dotenv", wantSource: "env", wantTick: 650 * time.Millisecond,
+		},
+		{
+			name:       "flag over env",
+			files:      map[string]string{"bot.json": `{"server": "http://file", "tick_interval": "700ms"}`},
+			env:        map[string]string{"BOT_SERVER": "http://env", "BOT_TICK_INTERVAL": "800"},
+			args:       []string{"-server", "http://flag"},
+			wantServer: "http://flag", wantSource: "flag", wantTick: 800 * time.Millisecond,
+		},
+		{
+			name:       "explicit config file",
+			files:      map[string]string{"bot.yaml": "server: http://default\n", "other.yaml": "server: http://other\n"},
+			args:       []string{"-config", "other.yaml"},
+			wantServer: "http://other", wantSource: "other.yaml", wantTick: 650 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := loadIn(t, tt.files, tt.env, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Server != tt.wantServer || c.sources["server"] != tt.wantSource {
+				t.Errorf("server = %q [%s], want %q [%s]", c.Server, c.sources["server"], tt.wantServer, tt.wantSource)
+			}
+			if c.TickInterval != tt.wantTick {
+				t.Errorf("tick_interval = %v, want %v", c.TickInterval, tt.wantTick)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		args  []string
+	}{
+		{name: "unknown key in file", files: map[string]string{"bot.yaml": "servr: http://x\n"}},
+		{name: "bad duration in env", env: map[string]string{"BOT_TICK_INTERVAL": "fast"}},
+		{name: "out of range flag", args: []string{"-viz-history", "-1"}},
+		{name: "unknown profile", args: []string{"-profile", "prod"}},
+		{name: "unknown file format", args: []string{"-config", "bot.toml"}, files: map[string]string{"bot.toml": "server = 1\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadIn(t, tt.files, tt.env, tt.args...); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadDotEnv читает .env в окружение. Уже заданные переменные не перекрывает:
+// export в терминале важнее файла. Понимает "export KEY=...", кавычки и комментарии.
+func LoadDotEnv(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Файла нет, не страшно
+		}
+		return err
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, i+1)
+		}
+		key = strings.TrimSpace(key)
+		value, err := unquote(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value)
+		}
+	}
+	return nil
+}
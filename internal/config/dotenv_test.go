@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDotEnv(t *testing.T) {
+	keys := []string{"DOTENV_PLAIN", "DOTENV_EXPORT", "DOTENV_QUOTED", "DOTENV_SINGLE", "DOTENV_COMMENT", "DOTENV_KEPT"}
+	for _, k := range keys {
+		t.Setenv(k, "") // вернет старое значение после теста
+		os.Unsetenv(k)
+	}
+	t.Setenv("DOTENV_KEPT", "from shell")
+
+	path := filepath.Join(t.TempDir(), ".env")
+	data := "# comment\n" +
+		"DOTENV_PLAIN=plain\n" +
+		"export DOTENV_EXPORT=exported\n" +
+		"DOTENV_QUOTED=\"a b # c\"\n" +
+		"DOTENV_SINGLE='it''s' # it's\n" +
+		"DOTENV_COMMENT=value # note\n" +
+		"DOTENV_KEPT=from file\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDotEnv(path); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"DOTENV_PLAIN":   "plain",
+		"DOTENV_EXPORT":  "exported",
+		"DOTENV_QUOTED":  "a b # c",
+		"DOTENV_SINGLE":  "it's",
+		"DOTENV_COMMENT": "value",
+		"DOTENV_KEPT":    "from shell",
+	}
+	for k, v := range want {
+		if got := os.Getenv(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestLoadDotEnvErrors(t *testing.T) {
+	if err := LoadDotEnv(filepath.Join(t.TempDir(), "missing.env")); err != nil {
+		t.Errorf("missing file: %v, want nil", err)
+	}
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("NOT_AN_ASSIGNMENT\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDotEnv(path); err == nil {
+		t.Error("line without =: want error")
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultFiles - где ищем конфиг, если путь не задан
+var defaultFiles = []string{"bot.yaml", "bot.yml", "bot.json"}
+
+// readConfigFile читает файл конфига в плоский набор ключ -> значение.
+// path == "" - берем первый из defaultFiles, если он есть.
+func readConfigFile(path string) (string, map[string]string, error) {
+	if path == "" {
+		for _, f := range defaultFiles {
+			if _, err := os.Stat(f); err == nil {
+				path = f
+				break
+			}
+		}
+		if path == "" {
+			return "", nil, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("config file: %w", err)
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		values, err = parseJSON(data)
+	case ".yaml", ".yml":
+		values, err = parseYAML(data)
+	default:
+		return "", nil, fmt.Errorf("config file %s: unknown format, use .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	for key := range values {
+		if !knownKey(key) {
+			return "", nil, fmt.Errorf("config file %s: unknown key %q%s", path, key, suggest(key, keyNames()))
+		}
+	}
+	return path, values, nil
+}
+
+// parseJSON - плоский объект; числа и булевы значения превращаются в строки
+func parseJSON(data []byte) (map[string]string, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case float64:
+			values[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			values[k] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("key %q: nested values are not supported", k)
+		}
+	}
+	return values, nil
+}
+
+// parseYAML понимает подмножество YAML, которого хватает для конфига:
+// строки "key: value", комментарии "#", значения в кавычках. Вложенности нет.
+func parseYAML(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("line %d: nested values are not supported", n)
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", n)
+		}
+		key = strings.TrimSpace(key)
+		value, err := unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", n, key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// unquote снимает кавычки; после закрывающей кавычки и у значения без кавычек отрезает комментарий "#..."
+func unquote(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	var value, rest string
+	switch v[0] {
+	case '"':
+		end := closingQuote(v, '"')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote in %s", v)
+		}
+		s, err := strconv.Unquote(v[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("bad quoted value %s: %w", v[:end+1], err)
+		}
+		value, rest = s, v[end+1:]
+	case '\'':
+		end := closingQuote(v, '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote in %s", v)
+		}
+		value, rest = strings.ReplaceAll(v[1:end], "''", "'"), v[end+1:]
+	default:
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		return v, nil
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after quoted value", rest)
+	}
+	return value, nil
+}
+
+// closingQuote - индекс кавычки, закрывающей v[0] (-1 - не закрыта). В двойных кавычках пропускаем
+// экранированное \", в одинарных - удвоенную одинарную: так YAML пишет кавычку внутри строки.
+func closingQuote(v string, q byte) int {
+	for i := 1; i < len(v); i++ {
+		switch {
+		case q == '"' && v[i] == '\\':
+			i++
+		case v[i] == q && q == '\'' && i+1 < len(v) && v[i+1] == '\'':
+			i++
+		case v[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+func knownKey(key string) bool {
+	for _, o := range options {
+		if o.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func keyNames() []string {
+	names := make([]string, 0, len(options))
+	for _, o := range options {
+		names = append(names, o.key)
+	}
+	return names
+}
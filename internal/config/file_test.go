@@ -0,0 +1,93 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "", want: ""},
+		{in: "plain", want: "plain"},
+		{in: "plain # comment", want: "plain"},
+		{in: "a#b", want: "a#b"}, // без пробела перед # это часть значения
+		{in: `"double"`, want: "double"},
+		{in: `"with # hash"`, want: "with # hash"},
+		{in: `"esc \" quote"`, want: `esc " quote`},
+		{in: `"tab\t"`, want: "tab\t"},
+		{in: `"x" # it's "quoted"`, want: "x"},
+		{in: `'single'`, want: "single"},
+		{in: `'it''s'`, want: "it's"},
+		{in: `'x' # it's a comment`, want: "x"},
+		{in: `'x'#tight`, want: "x"},
+		{in: `"open`, wantErr: true},
+		{in: `'open`, wantErr: true},
+		{in: `'it''`, wantErr: true},
+		{in: `"x" trailing`, wantErr: true},
+		{in: `"bad \q"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := unquote(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unquote(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("unquote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", in: "", want: map[string]string{}},
+		{
+			name: "values and comments",
+			in:   "---\n# comment\nserver: https://x.dev # prod\ntoken: 'ab''c' # it's secret\n\nviz_addr: \":9090\"\n",
+			want: map[string]string{"server": "https://x.dev", "token": "ab'c", "viz_addr": ":9090"},
+		},
+		{name: "colon in value", in: "server: http://h:1\n", want: map[string]string{"server": "http://h:1"}},
+		{name: "windows line endings", in: "strategy: default\r\n", want: map[string]string{"strategy": "default"}},
+		{name: "empty value", in: "record_dir:\n", want: map[string]string{"record_dir": ""}},
+		{name: "nested", in: "viz:\n  addr: :8080\n", wantErr: true},
+		{name: "no colon", in: "server\n", wantErr: true},
+		{name: "duplicate", in: "token: a\ntoken: b\n", wantErr: true},
+		{name: "bad quote", in: "token: 'a\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	got, err := parseJSON([]byte(`{"server": "https://x.dev", "viz_history": 120, "tick_interval": 0.5, "flag": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"server": "https://x.dev", "viz_history": "120", "tick_interval": "0.5", "flag": "true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err := parseJSON([]byte(`{"viz": {"addr": ":8080"}}`)); err == nil {
+		t.Error("nested object: want error")
+	}
+	if _, err := parseJSON([]byte(`{"server":`)); err == nil {
+		t.Error("broken JSON: want error")
+	}
+}
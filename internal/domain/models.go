@@ -6,6 +6,12 @@ type Vec2d [2]int
 func (v Vec2d) X() int { return v[0] }
 func (v Vec2d) Y() int { return v[1] }
 
+// Adjacent - клетки соседние по стороне: по диагонали юниты не ходят
+func (v Vec2d) Adjacent(o Vec2d) bool {
+	dx, dy := v[0]-o[0], v[1]-o[1]
+	return dx*dx+dy*dy == 1
+}
+
 // GameState - состояние мира (/api/arena)
 type GameState struct {
 	Arena    Arena         `json:"arena"`
@@ -30,8 +36,13 @@ type Unit struct {
 	Alive          bool   `json:"alive"`
 	BombCount      int    `json:"bombs_available"` // bombs_available
 	SafeTime       int    `json:"safe_time"`
+	CanMove        *bool  `json:"can_move"` // false - юнит еще идет по прошлому пути; nil - сервер не прислал
+	Armor          int    `json:"armor"`
 }
 
+// Moving - юнит еще не дошел по прошлому пути и новую команду на движение не примет
+func (u Unit) Moving() bool { return u.CanMove != nil && !*u.CanMove }
+
 type EnemyUnit struct {
 	ID       string `json:"id"`
 	Pos      Vec2d  `json:"pos"`
@@ -58,8 +69,8 @@ type AvailableBoosterResponse struct {
 }
 
 type Booster struct {
-	// ID мы будем вычислять сами или надеяться что он есть в JSON скрыто. 
-	// Пока добавим его для совместимости с logic.
+	// ID в ответе сервера нет (см. openapi view.AvailableBooster).
+	// Если вдруг появится - используем; иначе ID подбирает logic.BoosterRegistry.
 	ID   int    `json:"id"` 
 	Cost int    `json:"cost"`
 	Type string `json:"type"`
@@ -0,0 +1,214 @@
+package domain
+
+import "fmt"
+
+// MaxPathLen - сколько клеток пути можно передать за одну команду (doc.md)
+const MaxPathLen = 30
+
+// Нарушения правил /api/move. Сервер отбрасывает такие команды молча, поэтому ловим их до отправки.
+const (
+	RuleUnknownUnit  = "unknown or dead unit"
+	RuleMoving       = "unit is still moving"
+	RulePathTooLong  = "path longer than 30 cells"
+	RuleNotAdjacent  = "step is not adjacent to the previous cell"
+	RuleBlocked      = "step into a wall, obstacle or bomb"
+	RuleBombOffPath  = "bomb is not on the path"
+	RuleTooManyBombs = "more bombs than the unit has"
+)
+
+// Violation - нарушение в команде юнита и что с ней сделали
+type Violation struct {
+	UnitID  string
+	Rule    string
+	Detail  string
+	Dropped bool // команда отброшена целиком, иначе исправлена
+}
+
+func (v Violation) String() string {
+	action := "repaired"
+	if v.Dropped {
+		action = "dropped"
+	}
+	return fmt.Sprintf("unit %s: %s (%s), command %s", v.UnitID, v.Rule, v.Detail, action)
+}
+
+// CommandValidator проверяет команды по правилам doc.md на текущем снимке арены
+type CommandValidator struct {
+	size      Vec2d
+	units     map[string]Unit
+	walls     map[Vec2d]bool
+	obstacles map[Vec2d]bool
+	bombs     map[Vec2d]bool
+
+	canPassBombs     bool
+	canPassObstacles bool
+	canPassWalls     bool
+}
+
+// NewCommandValidator готовит проверку по состоянию арены; boosts может быть nil (усилений проходимости нет)
+func NewCommandValidator(state *GameState, boosts *BoosterState) *CommandValidator {
+	v := &CommandValidator{
+		size:      state.MapSize,
+		units:     make(map[string]Unit, len(state.MyUnits)),
+		walls:     make(map[Vec2d]bool, len(state.Arena.Walls)),
+		obstacles: make(map[Vec2d]bool, len(state.Arena.Obstacles)),
+		bombs:     make(map[Vec2d]bool, len(state.Arena.Bombs)),
+	}
+	for _, u := range state.MyUnits {
+		if u.Alive {
+			v.units[u.ID] = u
+		}
+	}
+	for _, w := range state.Arena.Walls {
+		v.walls[w] = true
+	}
+	for _, o := range state.Arena.Obstacles {
+		v.obstacles[o] = true
+	}
+	for _, b := range state.Arena.Bombs {
+		v.bombs[b.Pos] = true
+	}
+	if boosts != nil {
+		// Акробатика: 1 уровень - бомбы, 2 - разрушаемые препятствия, 3 - все препятствия
+		v.canPassBombs = boosts.CanPassBombs || boosts.CanPassObstacles || boosts.CanPassWalls
+		v.canPassObstacles = boosts.CanPassObstacles || boosts.CanPassWalls
+		v.canPassWalls = boosts.CanPassWalls
+	}
+	return v
+}
+
+// Validate исправляет или выбрасывает невалидные команды и возвращает все найденные нарушения
+func (v *CommandValidator) Validate(cmd PlayerCommand) (PlayerCommand, []Violation) {
+	out := PlayerCommand{Bombers: make([]UnitCommand, 0, len(cmd.Bombers))}
+	var all []Violation
+	for _, c := range cmd.Bombers {
+		fixed, ok, violations := v.validateUnit(c)
+		all = append(all, violations...)
+		if ok {
+			out.Bombers = append(out.Bombers, fixed)
+		}
+	}
+	return out, all
+}
+
+func (v *CommandValidator) validateUnit(cmd UnitCommand) (UnitCommand, bool, []Violation) {
+	var violations []Violation
+	report := func(rule, detail string) {
+		violations = append(violations, Violation{UnitID: cmd.ID, Rule: rule, Detail: detail})
+	}
+	drop := func() (UnitCommand, bool, []Violation) {
+		for i := range violations {
+			violations[i].Dropped = true
+		}
+		return UnitCommand{}, false, violations
+	}
+
+	u, ok := v.units[cmd.ID]
+	if !ok {
+		report(RuleUnknownUnit, "no alive unit with this ID")
+		return drop()
+	}
+	hadOrders := len(cmd.Path) > 0 || len(cmd.Bombs) > 0
+	if u.Moving() && hadOrders {
+		report(RuleMoving, fmt.Sprintf("at %v", u.Pos))
+		return drop()
+	}
+
+	path := cmd.Path
+	if len(path) > MaxPathLen {
+		report(RulePathTooLong, fmt.Sprintf("%d cells, cut to %d", len(path), MaxPathLen))
+		path = path[:MaxPathLen]
+	}
+
+	// Бомбы, которые юнит поставит раньше по пути, тоже мешают вернуться на эти клетки
+	wanted := make(map[Vec2d]bool, len(cmd.Bombs))
+	for _, b := range cmd.Bombs {
+		wanted[b] = true
+	}
+	placed := map[Vec2d]bool{}
+	if wanted[u.Pos] {
+		placed[u.Pos] = true
+	}
+	prev := u.Pos
+	for i, p := range path {
+		if !p.Adjacent(prev) {
+			report(RuleNotAdjacent, fmt.Sprintf("%v -> %v, cut to %d cells", prev, p, i))
+			path = path[:i]
+			break
+		}
+		if !v.passable(p) || placed[p] {
+			report(RuleBlocked, fmt.Sprintf("%v, cut to %d cells", p, i))
+			path = path[:i]
+			break
+		}
+		if wanted[p] {
+			placed[p] = true
+		}
+		prev = p
+	}
+
+	// Бомбы - только на клетках пути (текущая клетка тоже считается), в порядке прохождения
+	onPath := make(map[Vec2d]bool, len(path)+1)
+	onPath[u.Pos] = true
+	for _, p := range path {
+		onPath[p] = true
+	}
+	bombs := make([]Vec2d, 0, len(cmd.Bombs))
+	seen := map[Vec2d]bool{}
+	for _, b := range cmd.Bombs {
+		if seen[b] {
+			continue
+		}
+		seen[b] = true
+		if !onPath[b] {
+			report(RuleBombOffPath, fmt.Sprintf("%v removed", b))
+			continue
+		}
+		bombs = append(bombs, b)
+	}
+	if len(bombs) > u.BombCount {
+		report(RuleTooManyBombs, fmt.Sprintf("%d requested, %d available", len(bombs), u.BombCount))
+		bombs = firstOnPath(bombs, u.Pos, path, u.BombCount)
+	}
+
+	fixed := UnitCommand{ID: cmd.ID, Path: path, Bombs: bombs}
+	if hadOrders && len(path) == 0 && len(bombs) == 0 {
+		return drop()
+	}
+	return fixed, true, violations
+}
+
+// passable - можно ли шагнуть в клетку с текущими усилениями проходимости
+func (v *CommandValidator) passable(p Vec2d) bool {
+	if p.X() < 0 || p.Y() < 0 || p.X() >= v.size.X() || p.Y() >= v.size.Y() {
+		return false
+	}
+	switch {
+	case v.walls[p]:
+		return v.canPassWalls
+	case v.obstacles[p]:
+		return v.canPassObstacles
+	case v.bombs[p]:
+		return v.canPassBombs
+	}
+	return true
+}
+
+// firstOnPath оставляет limit бомб, которые юнит поставит раньше всех
+func firstOnPath(bombs []Vec2d, start Vec2d, path []Vec2d, limit int) []Vec2d {
+	want := make(map[Vec2d]bool, len(bombs))
+	for _, b := range bombs {
+		want[b] = true
+	}
+	out := make([]Vec2d, 0, limit)
+	for _, p := range append([]Vec2d{start}, path...) {
+		if len(out) == limit {
+			break
+		}
+		if want[p] {
+			out = append(out, p)
+			delete(want, p)
+		}
+	}
+	return out
+}
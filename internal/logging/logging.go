@@ -0,0 +1,187 @@
+// Package logging - структурные логи бота на log/slog: уровни, поля и свой уровень у каждого компонента.
+// Консоль получает текст, файл (если задан) - JSON, который удобно грепать после неудачного раунда.
+//
+// Уровни задаются строкой вида "info,unit=debug,pipeline=warn": первое - уровень по умолчанию,
+// дальше - компоненты со своим уровнем. В консоль идет только то, что не ниже уровня по умолчанию,
+// а подробности компонентов, включенные ниже него, пишутся только в файл (если файла нет - в консоль).
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Компоненты бота
+const (
+	App      = "app"      // запуск, конфиг, остановка
+	Round    = "round"    // жизненный цикл раунда
+	Pipeline = "pipeline" // конвейер тика и его статистика
+	Unit     = "unit"     // решения юнитов (трассы на debug)
+	Safety   = "safety"   // снятые бомбы и нарушения правил команды
+	Boosts   = "boosts"   // усиления и их покупка
+	State    = "state"    // снимки памяти бота
+	Record   = "record"   // записи раундов
+	API      = "api"      // ошибки запросов
+	Sim      = "sim"      // локальный симулятор
+)
+
+// Components - все компоненты, которым можно задать уровень
+var Components = []string{App, Round, Pipeline, Unit, Safety, Boosts, State, Record, API, Sim}
+
+// Levels - уровень по умолчанию и уровни компонентов
+type Levels struct {
+	Default    slog.Level
+	Components map[string]slog.Level
+}
+
+// Of - уровень компонента
+func (l Levels) Of(component string) slog.Level {
+	if lvl, ok := l.Components[component]; ok {
+		return lvl
+	}
+	return l.Default
+}
+
+// UnknownComponents - компоненты из уровней, которых нет в Components (для проверки конфига)
+func (l Levels) UnknownComponents() []string {
+	var unknown []string
+	for name := range l.Components {
+		known := false
+		for _, c := range Components {
+			known = known || c == name
+		}
+		if !known {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// ParseLevels разбирает "info,unit=debug,pipeline=warn". Имена компонентов не проверяются - это дело конфига.
+func ParseLevels(spec string) (Levels, error) {
+	l := Levels{Default: slog.LevelInfo, Components: map[string]slog.Level{}}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, level, isComponent := strings.Cut(part, "=")
+		if !isComponent {
+			level = name
+		}
+		var lvl slog.Level
+		if err := lvl.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+			return l, fmt.Errorf("%q: unknown level %q (use debug, info, warn or error)", part, level)
+		}
+		if isComponent {
+			l.Components[strings.TrimSpace(name)] = lvl
+		} else {
+			l.Default = lvl
+		}
+	}
+	return l, nil
+}
+
+// sinks - текущие приемники логов; For читает их на каждой записи, поэтому логгеры
+// можно заводить в переменных пакета до Setup
+type sinks struct {
+	levels  Levels
+	console slog.Handler
+	file    slog.Handler // nil - файла нет
+}
+
+var current atomic.Pointer[sinks]
+
+func init() {
+	current.Store(&sinks{
+		levels:  Levels{Default: slog.LevelInfo},
+		console: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	})
+	slog.SetDefault(For(App))
+}
+
+// Setup включает уровни spec и, если file не пуст, JSON-лог в файл (дописывается).
+// Стандартный log идет через slog - как компонент app.
+// Возвращаемая функция закрывает файл.
+func Setup(spec, file string) (func() error, error) {
+	levels, err := ParseLevels(spec)
+	if err != nil {
+		return nil, err
+	}
+	s := &sinks{
+		levels:  levels,
+		console: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	}
+	closeFile := func() error { return nil }
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		s.file = slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug})
+		closeFile = f.Close
+	}
+	current.Store(s)
+	slog.SetDefault(For(App))
+	return closeFile, nil
+}
+
+// For - логгер компонента; записи несут поле component
+func For(component string) *slog.Logger {
+	return slog.New(&handler{component: component}).With("component", component)
+}
+
+// handler фильтрует записи по уровню компонента и раздает их приемникам.
+// With/WithGroup копятся и применяются к приемникам при записи: приемники меняет Setup.
+type handler struct {
+	component string
+	wrap      []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= current.Load().levels.Of(h.component)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	s := current.Load()
+	if r.Level < s.levels.Of(h.component) {
+		return nil
+	}
+	var err error
+	if s.file != nil {
+		err = h.apply(s.file).Handle(ctx, r)
+	}
+	if s.file == nil || r.Level >= s.levels.Default {
+		if cerr := h.apply(s.console).Handle(ctx, r); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (h *handler) apply(sink slog.Handler) slog.Handler {
+	for _, w := range h.wrap {
+		sink = w(sink)
+	}
+	return sink
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(s slog.Handler) slog.Handler { return s.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(s slog.Handler) slog.Handler { return s.WithGroup(name) })
+}
+
+func (h *handler) with(w func(slog.Handler) slog.Handler) *handler {
+	wrap := make([]func(slog.Handler) slog.Handler, len(h.wrap), len(h.wrap)+1)
+	copy(wrap, h.wrap)
+	return &handler{component: h.component, wrap: append(wrap, w)}
+}
@@ -5,85 +5,75 @@ import (
 	"strings"
 )
 
-// ChooseBooster выбирает, что купить на основе текущих скилл-поинтов
-func ChooseBooster(available []domain.Booster, currentStats domain.BoosterState, state *domain.GameState) (int, bool) {
+// ChooseBooster выбирает, что купить на основе текущих скилл-поинтов.
+// Возвращает тип усиления (Boost*); ID для /api/booster подбирает BoosterRegistry.
+func ChooseBooster(available []domain.Booster, currentStats domain.BoosterState, state *domain.GameState) (string, bool) {
 	if currentStats.Points <= 0 {
-		return 0, false
+		return "", false
 	}
 
 	// 1. Радиус бомбы (приоритет)
 	if currentStats.BombRange < 3 {
-		if id, ok := findBooster(available, "range", currentStats.Points); ok {
-			return id, true
+		if kind, ok := findBooster(available, BoostRange, currentStats.Points); ok {
+			return kind, true
 		}
 	}
 
 	// 2. Фитиль (уменьшение задержки)
 	// База 8000, -2000 за апгрейд. Минимум (после 3 апгрейдов) = 2000.
 	if currentStats.BombDelay > 2000 {
-		if id, ok := findBooster(available, "delay", currentStats.Points); ok {
-			return id, true
+		if kind, ok := findBooster(available, BoostDelay, currentStats.Points); ok {
+			return kind, true
 		}
 	}
 
 	// 3. Количество бомб
 	if currentStats.MaxBombs < 3 {
-		if id, ok := findBooster(available, "bomb", currentStats.Points); ok {
-			// Важно: исключаем "bomb_delay" если ищем просто "bomb"
-			// Но функция findBooster ищет подстроку. 
-			// "bomb" найдется в "buff_bomb" и "buff_bomb_delay".
-			// Нужно уточнить поиск.
-			return id, true
+		if kind, ok := findBooster(available, BoostBombs, currentStats.Points); ok {
+			return kind, true
 		}
 	}
 
 	// 4. Еще радиус
 	if currentStats.BombRange < 5 {
-		if id, ok := findBooster(available, "range", currentStats.Points); ok {
-			return id, true
+		if kind, ok := findBooster(available, BoostRange, currentStats.Points); ok {
+			return kind, true
 		}
 	}
 	
 	// 5. Еще бомбы
 	if currentStats.MaxBombs < 5 {
-		if id, ok := findBooster(available, "bomb", currentStats.Points); ok {
-			return id, true
+		if kind, ok := findBooster(available, BoostBombs, currentStats.Points); ok {
+			return kind, true
 		}
 	}
 
 	// 6. Скорость (остальное)
 	if currentStats.Speed < 3 { 
-		if id, ok := findBooster(available, "speed", currentStats.Points); ok {
-			return id, true
+		if kind, ok := findBooster(available, BoostSpeed, currentStats.Points); ok {
+			return kind, true
 		}
 	}
 
 	// Fallback
-	if id, ok := findBooster(available, "range", currentStats.Points); ok { return id, true }
-	if id, ok := findBooster(available, "bomb", currentStats.Points); ok { return id, true }
+	if kind, ok := findBooster(available, BoostRange, currentStats.Points); ok { return kind, true }
+	if kind, ok := findBooster(available, BoostBombs, currentStats.Points); ok { return kind, true }
 
-	return 0, false
+	return "", false
 }
 
-func findBooster(list []domain.Booster, typeName string, budget int) (int, bool) {
+// findBooster ищет доступное усиление по типу (см. boosterKind: "bomb" больше не путается
+// с "bomb_delay" и "bombers")
+func findBooster(list []domain.Booster, kind string, budget int) (string, bool) {
 	for _, b := range list {
-		t := strings.ToLower(b.Type)
-		
-		// Special handling to avoid confusing "bomb" with "bomb_delay"
-		if typeName == "bomb" && strings.Contains(t, "delay") {
-			continue
-		}
-
-		if strings.Contains(t, typeName) && b.Cost <= budget {
-			if b.ID != 0 {
-				return b.ID, true
-			}
-			return mapTypeToID(b.Type), true
+		if boosterKind(b.Type) == kind && b.Cost <= budget {
+			return kind, true
 		}
 	}
-	return 0, false
+	return "", false
 }
 
+// mapTypeToID - последняя догадка об ID по названию, если ничего лучше нет
 func mapTypeToID(t string) int {
 	t = strings.ToLower(t)
 	switch {
@@ -0,0 +1,173 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorutin/internal/domain"
+	"os"
+	"sync"
+)
+
+// BoosterRegistry подбирает ID усиления для POST /api/booster.
+// Сервер не отдает ID в списке available, поэтому пробуем по очереди:
+// выученное значение -> ID из JSON (если вдруг есть) -> индекс в available -> догадка по названию.
+// После покупки сверяем BoosterState: что реально выросло, то и купили.
+type BoosterRegistry struct {
+	mu      sync.Mutex
+	path    string
+	server  string
+	learned map[string]map[string]int // server -> kind -> id
+	failed  map[string]map[int]bool   // kind -> id, которые ничего не дали
+	Wrong   []BoosterOutcome          // неверные покупки за сессию
+}
+
+// BoosterOutcome - результат покупки после сверки состояния
+type BoosterOutcome struct {
+	Wanted string `json:"wanted"`
+	Got    string `json:"got"` // "" - ничего не изменилось
+	ID     int    `json:"id"`
+}
+
+// Wrong - купили не то, что хотели
+func (o BoosterOutcome) Wrong() bool { return o.Got != "" && o.Got != o.Wanted }
+
+func (o BoosterOutcome) String() string {
+	switch {
+	case o.Got == o.Wanted:
+		return fmt.Sprintf("booster %s confirmed as ID=%d", o.Wanted, o.ID)
+	case o.Got == "":
+		return fmt.Sprintf("booster ID=%d for %s changed nothing", o.ID, o.Wanted)
+	}
+	return fmt.Sprintf("WRONG PURCHASE: wanted %s, ID=%d bought %s", o.Wanted, o.ID, o.Got)
+}
+
+// NewBoosterRegistry загружает выученные ID для server из path (если файл есть).
+// Реестр возвращается всегда; ошибка - файл есть, но прочитать его не вышло, и учимся заново.
+func NewBoosterRegistry(path, server string) (*BoosterRegistry, error) {
+	r := &BoosterRegistry{
+		path:    path,
+		server:  server,
+		learned: make(map[string]map[string]int),
+		failed:  make(map[string]map[int]bool),
+	}
+	var loadErr error
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &r.learned); err != nil {
+			r.learned = make(map[string]map[string]int)
+			loadErr = fmt.Errorf("parse %s: %w", path, err)
+		}
+	} else if path != "" && !errors.Is(err, os.ErrNotExist) {
+		loadErr = err
+	}
+	if r.learned[server] == nil {
+		r.learned[server] = make(map[string]int)
+	}
+	return r, loadErr
+}
+
+// ForgetFailed дает отбракованным ID второй шанс: зовется с началом раунда.
+// Неудача могла быть случайной, а без этого тип усиления остался бы некупленным до перезапуска.
+func (r *BoosterRegistry) ForgetFailed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = make(map[string]map[int]bool)
+}
+
+// Resolve - ID, который стоит попробовать для kind
+func (r *BoosterRegistry) Resolve(available []domain.Booster, kind string) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range r.candidates(available, kind) {
+		if !r.failed[kind][id] {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func (r *BoosterRegistry) candidates(available []domain.Booster, kind string) []int {
+	ids := []int{}
+	if id, ok := r.learned[r.server][kind]; ok {
+		ids = append(ids, id)
+	}
+	for i, b := range available {
+		if boosterKind(b.Type) != kind {
+			continue
+		}
+		if b.ID != 0 {
+			ids = append(ids, b.ID)
+		}
+		ids = append(ids, i, mapTypeToID(b.Type))
+	}
+	return ids
+}
+
+// Record сверяет состояние до и после покупки, запоминает верный ID и сохраняет его на диск.
+// Звать только для подтвержденной покупки: иначе сверять не с чем. Ошибка - не удалось сохранить.
+func (r *BoosterRegistry) Record(kind string, id int, before, after domain.BoosterState) (BoosterOutcome, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := BoosterOutcome{Wanted: kind, Got: boostDiff(before, after), ID: id}
+	switch {
+	case out.Got == "":
+		if r.failed[kind] == nil {
+			r.failed[kind] = make(map[int]bool)
+		}
+		r.failed[kind][id] = true
+		if r.learned[r.server][kind] == id {
+			delete(r.learned[r.server], kind)
+		}
+	case out.Wrong():
+		r.Wrong = append(r.Wrong, out)
+		r.learned[r.server][out.Got] = id
+		if r.learned[r.server][kind] == id {
+			delete(r.learned[r.server], kind)
+		}
+		if r.failed[kind] == nil {
+			r.failed[kind] = make(map[int]bool)
+		}
+		r.failed[kind][id] = true
+	default:
+		r.learned[r.server][kind] = id
+	}
+	return out, r.save()
+}
+
+// save пишет выученное в файл; без файла учимся только в памяти
+func (r *BoosterRegistry) save() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.learned, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+// boostDiff - какое усиление применилось между двумя состояниями ("" - ничего)
+func boostDiff(before, after domain.BoosterState) string {
+	switch {
+	case after.BombDelay < before.BombDelay:
+		return BoostDelay
+	case after.BombRange > before.BombRange:
+		return BoostRange
+	case after.MaxBombs > before.MaxBombs:
+		return BoostBombs
+	case after.Speed > before.Speed:
+		return BoostSpeed
+	case after.View > before.View:
+		return BoostView
+	case after.Bombers > before.Bombers:
+		return BoostBombers
+	case after.Armor > before.Armor:
+		return BoostArmor
+	case after.CanPassBombs != before.CanPassBombs,
+		after.CanPassObstacles != before.CanPassObstacles,
+		after.CanPassWalls != before.CanPassWalls:
+		return BoostAcrobatics
+	}
+	return ""
+}
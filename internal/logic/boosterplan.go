@@ -0,0 +1,370 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+	"strings"
+	"time"
+)
+
+// Типы усилений (doc.md, "Усиление юнитов")
+const (
+	BoostBombs      = "bombs"      // карманы: +1 бомба
+	BoostRange      = "range"      // +1 радиус
+	BoostSpeed      = "speed"      // +1 скорость, максимум 3 улучшения
+	BoostView       = "view"       // +3 обзор
+	BoostBombers    = "bombers"    // софт скилы: +1 юнит, 2 очка
+	BoostArmor      = "armor"      // +1 броня
+	BoostDelay      = "delay"      // -2с фитиль, максимум 3 улучшения
+	BoostAcrobatics = "acrobatics" // проходимость, 2 очка, 3 уровня
+)
+
+const (
+	skillPointEvery   = 90 * time.Second // скилл поинт каждые 1.5 минуты
+	skillPointsMax    = 10               // максимум за раунд
+	baseBombDelayMs   = 8000
+	baseSpeed         = 2
+	baseView          = 5
+	deathCostSeconds  = 20.0 // сколько секунд фарма теряет погибший юнит
+	threatDeathPerSec = 0.01 // вероятность смерти юнита в секунду на единицу угрозы
+)
+
+var boosterKinds = []string{BoostBombs, BoostRange, BoostSpeed, BoostView, BoostBombers, BoostArmor, BoostDelay, BoostAcrobatics}
+
+var defaultBoosterCost = map[string]int{BoostBombers: 2, BoostAcrobatics: 2}
+
+// boosterKind приводит тип из /api/booster к одному из Boost* ("" - не распознан)
+func boosterKind(t string) string {
+	t = strings.ToLower(t)
+	switch {
+	case strings.Contains(t, "delay"), strings.Contains(t, "fuse"):
+		return BoostDelay
+	case strings.Contains(t, "range"):
+		return BoostRange
+	case strings.Contains(t, "speed"):
+		return BoostSpeed
+	case strings.Contains(t, "view"), strings.Contains(t, "vision"):
+		return BoostView
+	case strings.Contains(t, "armor"):
+		return BoostArmor
+	case strings.Contains(t, "bomber"), strings.Contains(t, "soft"), strings.Contains(t, "unit"):
+		return BoostBombers
+	case strings.Contains(t, "acrobat"), strings.Contains(t, "pass"):
+		return BoostAcrobatics
+	case strings.Contains(t, "bomb"), strings.Contains(t, "pocket"):
+		return BoostBombs
+	}
+	return ""
+}
+
+// BoosterPlanInput - все, что нужно планировщику о текущем раунде
+type BoosterPlanInput struct {
+	Stats     domain.BoosterState
+	Available []domain.Booster
+	Elapsed   time.Duration // с начала раунда
+	Remaining time.Duration // до конца раунда
+	Units     int
+	Density   float64 // доля препятствий среди известных свободных клеток
+	Threat    float64 // враги и проснувшиеся мобы на одного нашего юнита
+}
+
+// BoosterPlan - порядок покупок до конца раунда и его ожидаемая ценность
+type BoosterPlan struct {
+	Sequence []string `json:"sequence"`
+	Expected float64  `json:"expected"` // ожидаемые очки до конца раунда
+	Baseline float64  `json:"baseline"` // без покупок
+	Saving   bool     `json:"saving"`   // копим на дорогое усиление
+}
+
+// Next - что купить прямо сейчас. ok=false - либо нечего, либо копим.
+func (p BoosterPlan) Next(points int, in BoosterPlanInput) (string, bool) {
+	if len(p.Sequence) == 0 {
+		return "", false
+	}
+	kind := p.Sequence[0]
+	return kind, boosterCost(in.Available, kind) <= points
+}
+
+// PlanBoosters жадно строит последовательность покупок, каждый раз добавляя то усиление,
+// которое сильнее всего увеличивает ожидаемые очки с учетом расписания скилл поинтов.
+// Дорогие (2 очка) усиления попадают в план, только если ради них выгодно копить.
+func PlanBoosters(in BoosterPlanInput) BoosterPlan {
+	plan := BoosterPlan{Baseline: simulateBoosters(in, nil)}
+	plan.Expected = plan.Baseline
+	budget := in.Stats.Points + futurePoints(in.Elapsed, in.Remaining)
+
+	spent := 0
+	for {
+		bestKind, bestValue := "", plan.Expected
+		for _, kind := range boosterKinds {
+			cost := boosterCost(in.Available, kind)
+			if cost == 0 || spent+cost > budget || !canUpgrade(in.Stats, append(plan.Sequence, kind), kind) {
+				continue
+			}
+			if v := simulateBoosters(in, append(plan.Sequence, kind)); v > bestValue+1e-9 {
+				bestKind, bestValue = kind, v
+			}
+		}
+		if bestKind == "" {
+			break
+		}
+		plan.Sequence = append(plan.Sequence, bestKind)
+		plan.Expected = bestValue
+		spent += boosterCost(in.Available, bestKind)
+	}
+
+	if len(plan.Sequence) > 0 && in.Stats.Points > 0 {
+		plan.Saving = boosterCost(in.Available, plan.Sequence[0]) > in.Stats.Points
+	}
+	return plan
+}
+
+// futurePoints - сколько скилл поинтов еще придет до конца раунда
+func futurePoints(elapsed, remaining time.Duration) int {
+	earned := int(elapsed / skillPointEvery)
+	if earned >= skillPointsMax {
+		return 0
+	}
+	total := int((elapsed + remaining) / skillPointEvery)
+	if total > skillPointsMax {
+		total = skillPointsMax
+	}
+	return total - earned
+}
+
+// simulateBoosters проигрывает остаток раунда: очки приходят по расписанию,
+// усиления покупаются по порядку, как только на них хватает
+func simulateBoosters(in BoosterPlanInput, seq []string) float64 {
+	stats := in.Stats
+	units := in.Units
+	points := stats.Points
+	now := 0.0
+	end := in.Remaining.Seconds()
+
+	// Моменты прихода следующих очков (в секундах от текущего момента)
+	arrivals := []float64{}
+	earned := int(in.Elapsed / skillPointEvery)
+	for k := earned + 1; k <= skillPointsMax; k++ {
+		t := (time.Duration(k)*skillPointEvery - in.Elapsed).Seconds()
+		if t >= end {
+			break
+		}
+		arrivals = append(arrivals, t)
+	}
+
+	total := 0.0
+	next := 0
+	for {
+		// Покупаем все, на что хватает
+		for next < len(seq) {
+			cost := boosterCost(in.Available, seq[next])
+			if cost > points {
+				break
+			}
+			points -= cost
+			applyBoost(&stats, &units, seq[next])
+			next++
+		}
+		until := end
+		if len(arrivals) > 0 && arrivals[0] < end {
+			until = arrivals[0]
+		}
+		total += scoreRate(stats, units, in) * (until - now)
+		now = until
+		if len(arrivals) == 0 || now >= end {
+			break
+		}
+		arrivals = arrivals[1:]
+		points++
+	}
+	return total
+}
+
+// scoreRate - грубая модель очков в секунду для команды с данными усилениями
+func scoreRate(s domain.BoosterState, units int, in BoosterPlanInput) float64 {
+	delay := float64(s.BombDelay) / 1000
+	if delay <= 0 {
+		delay = baseBombDelayMs / 1000
+	}
+	speed := float64(max(s.Speed, baseSpeed))
+	bombs := float64(max(s.MaxBombs, 1))
+	rng := float64(max(s.BombRange, 1))
+
+	travel := 4.0 / speed // дойти до следующей позиции
+	if s.CanPassBombs {
+		travel *= 0.85
+	}
+	if s.CanPassObstacles {
+		travel *= 0.8
+	}
+
+	// Ящиков на бомбу: на плотной карте радиус чаще находит препятствие в каждом из 4 лучей
+	hitChance := 1 - math.Pow(1-in.Density, rng)
+	boxes := 4 * hitChance
+	perBomb := boxes * (boxes + 1) / 2 // 1+2+3+4 за несколько ящиков одним взрывом
+	cycle := math.Max(delay/bombs, travel)
+	perUnit := perBomb / cycle
+
+	view := float64(max(s.View, baseView))
+	perUnit *= 1 + 0.03*(view-baseView) // дальше видим - меньше бродим без цели
+
+	death := in.Threat * threatDeathPerSec / float64(1+s.Armor)
+	perUnit -= death * deathCostSeconds * perUnit
+
+	return math.Max(perUnit, 0) * float64(units)
+}
+
+func applyBoost(s *domain.BoosterState, units *int, kind string) {
+	switch kind {
+	case BoostBombs:
+		s.MaxBombs = max(s.MaxBombs, 1) + 1
+	case BoostRange:
+		s.BombRange = max(s.BombRange, 1) + 1
+	case BoostSpeed:
+		s.Speed = max(s.Speed, baseSpeed) + 1
+	case BoostView:
+		s.View = max(s.View, baseView) + 3
+	case BoostBombers:
+		s.Bombers++
+		*units++
+	case BoostArmor:
+		s.Armor++
+	case BoostDelay:
+		if s.BombDelay <= 0 {
+			s.BombDelay = baseBombDelayMs
+		}
+		s.BombDelay -= 2000
+	case BoostAcrobatics:
+		switch {
+		case !s.CanPassBombs:
+			s.CanPassBombs = true
+		case !s.CanPassObstacles:
+			s.CanPassObstacles = true
+		default:
+			s.CanPassWalls = true
+		}
+	}
+}
+
+// canUpgrade проверяет лимиты (скорость и фитиль - по 3 улучшения, акробатика - 3 уровня)
+func canUpgrade(s domain.BoosterState, seq []string, kind string) bool {
+	units := 0
+	for _, k := range seq[:len(seq)-1] {
+		applyBoost(&s, &units, k)
+	}
+	switch kind {
+	case BoostSpeed:
+		return max(s.Speed, baseSpeed)-baseSpeed < 3
+	case BoostDelay:
+		return s.BombDelay == 0 || s.BombDelay > baseBombDelayMs-3*2000
+	case BoostAcrobatics:
+		return !s.CanPassWalls
+	}
+	return true
+}
+
+// boosterCost - цена из /api/booster, иначе по doc.md. 0 - сейчас недоступно.
+func boosterCost(available []domain.Booster, kind string) int {
+	if len(available) == 0 {
+		if c, ok := defaultBoosterCost[kind]; ok {
+			return c
+		}
+		return 1
+	}
+	for _, b := range available {
+		if boosterKind(b.Type) == kind {
+			return max(b.Cost, 1)
+		}
+	}
+	return 0
+}
+
+// PickBooster выбирает тип покупки по плану на остаток раунда; если время раунда неизвестно -
+// по фиксированным приоритетам ChooseBooster
+func (b *Bot) PickBooster(available []domain.Booster, stats domain.BoosterState) (string, bool) {
+	plan, in, ok := b.PlanBoosters(available, stats)
+	if !ok {
+		return ChooseBooster(available, stats, b.State)
+	}
+	kind, buy := plan.Next(stats.Points, in)
+	if !buy {
+		return "", false
+	}
+	return findBooster(available, kind, stats.Points)
+}
+
+// PlanBoosters строит план по текущему состоянию бота (плотность карты, угроза, время раунда).
+// ok=false, если время раунда неизвестно - тогда используем ChooseBooster.
+func (b *Bot) PlanBoosters(available []domain.Booster, stats domain.BoosterState) (BoosterPlan, BoosterPlanInput, bool) {
+	if b.RoundEnd.IsZero() || b.RoundStart.IsZero() || b.State == nil || b.Grid == nil {
+		return BoosterPlan{}, BoosterPlanInput{}, false
+	}
+	now := b.now()
+	in := BoosterPlanInput{
+		Stats:     stats,
+		Available: available,
+		Elapsed:   now.Sub(b.RoundStart),
+		Remaining: b.RoundEnd.Sub(now),
+		Density:   b.mapDensity(),
+		Threat:    b.threatLevel(),
+	}
+	for _, u := range b.State.MyUnits {
+		if u.Alive {
+			in.Units++
+		}
+	}
+	if in.Remaining <= 0 {
+		return BoosterPlan{}, in, false
+	}
+	b.BoosterPlan = PlanBoosters(in)
+	return b.BoosterPlan, in, true
+}
+
+// mapDensity - доля разрушаемых препятствий среди клеток в обзоре наших юнитов (остальное - туман)
+func (b *Bot) mapDensity() float64 {
+	const view = baseView
+	seen := make(map[domain.Vec2d]bool)
+	boxes, open := 0, 0
+	for _, u := range b.State.MyUnits {
+		if !u.Alive {
+			continue
+		}
+		for dx := -view; dx <= view; dx++ {
+			for dy := -view; dy <= view; dy++ {
+				p := domain.Vec2d{u.Pos.X() + dx, u.Pos.Y() + dy}
+				if seen[p] || !b.isValid(p) || !inRadius(u.Pos, p, view) {
+					continue
+				}
+				seen[p] = true
+				switch b.Grid[p.X()][p.Y()] {
+				case TileBox:
+					boxes++
+				case TileWall:
+				default:
+					open++
+				}
+			}
+		}
+	}
+	if boxes+open == 0 {
+		return 0
+	}
+	return float64(boxes) / float64(boxes+open)
+}
+
+// threatLevel - враги и бодрствующие мобы в обзоре на одного живого юнита
+func (b *Bot) threatLevel() float64 {
+	alive := 0
+	for _, u := range b.State.MyUnits {
+		if u.Alive {
+			alive++
+		}
+	}
+	threats := len(b.State.Enemies)
+	for _, m := range b.State.Mobs {
+		if m.SafeTime <= 0 {
+			threats++
+		}
+	}
+	return float64(threats) / float64(max(alive, 1))
+}
@@ -20,35 +20,88 @@ const (
 type Bot struct {
 	State       *domain.GameState
 	Grid        [][]int
+	Detonation  map[domain.Vec2d]float64 // секунд до взрыва в клетке (с учетом цепочек)
 
 	BombRange int
+	BombDelay int // мс до взрыва
 	Speed     int
 	MaxBombs  int
 	Tick      int
 
+	TickInterval time.Duration    // как часто вызывается CalculateTurn
+	Clock        func() time.Time // часы бота (nil - настоящие); симулятор подставляет свои
+
 	UnitTargets     map[string]*domain.Vec2d
 	UnitExploreDirs map[string]domain.Vec2d
 	MemoryTargets   map[domain.Vec2d]int
 	AssignedTargets map[domain.Vec2d]string
+	MobTracks       map[string]*MobTrack
+	Enemies         *EnemyTracker
+	Orders          map[string]*UnitOrder
+	Units           map[string]*UnitInfo // живые юниты: роль, область
+	Regions         [][]string           // владелец клетки по partitionTerritory ("" - ничья)
+	LastSeen        [][]int              // тик, когда клетку последний раз видел кто-то из наших (0 - никогда)
+	View            int                  // радиус обзора юнита
+	Chain           *ChainPlan
+	ChainStats      ChainStats
+	RoundStart      time.Time // начало текущего раунда, если знаем
+	RoundEnd        time.Time // конец текущего раунда, если знаем
+	Respawn         RespawnDecision
+	BoosterPlan     BoosterPlan
+	FriendlyFire    []FriendlyFire            // бомбы, снятые validateTeam на этом тике
+	Decisions       []UnitDecision            // почему юниты получили свои команды на этом тике
+	Escapes         map[string][]domain.Vec2d // пути отхода юнитов на этом тике (из зоны взрыва или от своей бомбы), с клетки юнита
+	Strategy        Strategy
+
+	scoreHistory []scoreSample
+	rolesTick    int // тик последнего распределения ролей (0 - пора пересчитать)
+
+	mobPaths map[string][]domain.Vec2d // предсказанные траектории мобов на текущий тик
+	decision UnitDecision              // решение юнита, которое сейчас принимает decideUnitAction
 }
 
 func NewBot() *Bot {
 	rand.Seed(time.Now().UnixNano())
 	return &Bot{
 		BombRange:       1,
+		BombDelay:       8000,
 		Speed:           2,
 		MaxBombs:        1,
+		View:            baseView,
+		TickInterval:    650 * time.Millisecond,
 		UnitTargets:     make(map[string]*domain.Vec2d),
 		UnitExploreDirs: make(map[string]domain.Vec2d),
 		MemoryTargets:   make(map[domain.Vec2d]int),
 		AssignedTargets: make(map[domain.Vec2d]string),
+		MobTracks:       make(map[string]*MobTrack),
+		Enemies:         NewEnemyTracker(),
+		Orders:          make(map[string]*UnitOrder),
+		Units:           make(map[string]*UnitInfo),
+		Strategy:        Strategies[DefaultStrategy],
+	}
+}
+
+// Reset забывает все, что бот узнал за раунд: по doc.md с началом раунда прогресс сбрасывается.
+// Замеренный интервал тика - свойство сети, а стратегия и часы - конфига, их оставляем.
+func (b *Bot) Reset() {
+	interval, strategy, clock := b.TickInterval, b.Strategy, b.Clock
+	*b = *NewBot()
+	b.TickInterval, b.Strategy, b.Clock = interval, strategy, clock
+}
+
+func (b *Bot) now() time.Time {
+	if b.Clock != nil {
+		return b.Clock()
 	}
+	return time.Now()
 }
 
 func (b *Bot) UpdateBoosterState(state domain.BoosterState) {
 	if state.BombRange > 0 { b.BombRange = state.BombRange }
+	if state.BombDelay > 0 { b.BombDelay = state.BombDelay }
 	if state.Speed > 0 { b.Speed = state.Speed }
 	if state.MaxBombs > 0 { b.MaxBombs = state.MaxBombs }
+	if state.View > 0 { b.View = state.View }
 }
 
 func (b *Bot) GetGrid() [][]int {
@@ -63,18 +116,20 @@ func (b *Bot) GetGrid() [][]int {
 func (b *Bot) CalculateTurn(state *domain.GameState) *domain.PlayerCommand {
 	b.State = state
 	b.Tick++
+	now := b.now()
 
 	b.initGrid()
 	b.fillGrid()
+	b.updateMobTracks()
+	b.Enemies.Update(state.Enemies, b.Tick, now)
 	b.updateGlobalTargets()
 	b.cleanMemory()
+	b.syncUnits()
 
 	aliveUnits := []domain.Unit{}
 	for _, u := range state.MyUnits {
 		if u.Alive {
 			aliveUnits = append(aliveUnits, u)
-		} else {
-			b.releaseTarget(u.ID)
 		}
 	}
 
@@ -82,16 +137,39 @@ func (b *Bot) CalculateTurn(state *domain.GameState) *domain.PlayerCommand {
 		return aliveUnits[i].ID < aliveUnits[j].ID
 	})
 
-	suicideMode := len(state.MyUnits) > 1 && len(aliveUnits) == 1
+	b.recordScore(now, len(aliveUnits))
+	b.partitionTerritory(aliveUnits)
+	b.updateFog(aliveUnits)
+	b.assignRoles(aliveUnits)
+
+	// Последний выживший жертвует собой, только если возрождение выгоднее (штраф 10% очков)
+	suicideMode := false
+	if b.Strategy.Respawn && len(state.MyUnits) > 1 && len(aliveUnits) == 1 {
+		b.Respawn = b.decideRespawn(now, len(state.MyUnits))
+		suicideMode = b.Respawn.Sacrifice
+	}
+	if b.Strategy.Traps {
+		b.planTraps(aliveUnits)
+	}
+	if b.Strategy.Chains {
+		b.planChain(aliveUnits)
+	}
 	commands := []domain.UnitCommand{}
+	b.Decisions = nil
+	b.Escapes = make(map[string][]domain.Vec2d)
 
 	for _, unit := range aliveUnits {
+		b.decision = UnitDecision{Unit: unit.ID, Phase: PhaseIdle}
 		cmd := b.decideUnitAction(unit, suicideMode)
 		if cmd != nil {
 			commands = append(commands, *cmd)
 		}
+		b.Decisions = append(b.Decisions, b.decision)
 	}
 
+	commands = b.validateTeam(aliveUnits, commands, suicideMode)
+	b.finishDecisions(commands)
+
 	if len(commands) == 0 { return nil }
 	return &domain.PlayerCommand{Bombers: commands}
 }
@@ -108,7 +186,8 @@ func (b *Bot) updateGlobalTargets() {
 		}
 	}
 	for _, enemy := range b.State.Enemies {
-		for _, n := range b.neighbors(enemy.Pos) {
+		if !killableAt(enemy.SafeTime, b.BombDelay) { continue }
+		for _, n := range b.neighbors(b.enemyPosAtBlast(enemy)) {
 			if b.isWalkable(n) {
 				if score := b.evaluatePos(n); score > 0 {
 					b.MemoryTargets[n] = score
@@ -116,6 +195,7 @@ func (b *Bot) updateGlobalTargets() {
 			}
 		}
 	}
+	b.addMobHuntTargets()
 }
 
 func (b *Bot) releaseTarget(unitID string) {
@@ -132,17 +212,29 @@ func (b *Bot) assignTarget(unitID string, target domain.Vec2d) {
 
 func (b *Bot) decideUnitAction(u domain.Unit, suicideMode bool) *domain.UnitCommand {
 	// 0. ВЫЖИВАНИЕ (Skip if suicideMode)
+	// Неуязвимый после возрождения юнит может стоять в опасной клетке, пока защита не кончится
 	if !suicideMode {
-		if b.isTileDangerous(u.Pos) {
+		if b.isTileDangerous(u.Pos) && !b.invulnerableAt(u, u.Pos) {
 			b.releaseTarget(u.ID)
+			b.decide(PhaseSurvival, "", nil, 0)
 			safePath := b.findSafePath(u.Pos)
 			if len(safePath) > 1 {
+				b.Escapes[u.ID] = safePath
 				return &domain.UnitCommand{ID: u.ID, Path: safePath[1:]}
 			}
+			b.decision.Detail = "trapped"
 			return nil
 		}
 	}
 
+	// 0.5 ТАКТИКА (ловушки и т.п.) - задания важнее обычных целей
+	if order := b.Orders[u.ID]; order != nil {
+		if cmd := b.executeOrder(u, order, suicideMode); cmd != nil {
+			b.decide(PhaseOrder, order.Kind, &order.Target, 0)
+			return cmd
+		}
+	}
+
 	// 1. СКАНИРОВАНИЕ
 	b.scanArea(u.Pos)
 
@@ -151,6 +243,7 @@ func (b *Bot) decideUnitAction(u domain.Unit, suicideMode bool) *domain.UnitComm
 	// При этом Survival (шаг 0) все еще работает и уведет нас, если станет опасно
 	target := b.UnitTargets[u.ID]
 	if target != nil && u.Pos == *target && u.BombCount == 0 {
+		b.decide(PhaseTarget, "wait", target, b.MemoryTargets[*target])
 		return &domain.UnitCommand{ID: u.ID}
 	}
 
@@ -174,29 +267,24 @@ func (b *Bot) decideUnitAction(u domain.Unit, suicideMode bool) *domain.UnitComm
 	}
 
 	if target != nil {
+		b.decide(PhaseTarget, "move", target, b.MemoryTargets[*target])
 		if u.Pos == *target {
 			if u.BombCount > 0 {
-				escapePath, isSafe := b.getBlastSafePath(u.Pos)
-				if isSafe || suicideMode {
-					b.simulateLocalBomb(u.Pos)
-					b.cleanMemory()
-					
+				if cmd, ok := b.bombAndEscape(u, nil, suicideMode); ok {
+					b.decision.Detail = "bomb"
 					b.releaseTarget(u.ID)
 					delete(b.MemoryTargets, *target)
-
-					cmd := domain.UnitCommand{ID: u.ID, Bombs: []domain.Vec2d{u.Pos}}
-					if isSafe && !suicideMode && len(escapePath) > 1 {
-						cmd.Path = escapePath[1:]
-					}
-					return &cmd
+					return cmd
 				} else {
 					// Небезопасно ставить бомбу здесь.
 					// Удаляем эту точку из целей, чтобы бот нашел другую (например, с другой стороны ящика)
+					b.decision.Detail = "unsafe"
 					b.releaseTarget(u.ID)
 					delete(b.MemoryTargets, *target)
 				}
 			} else {
 				// У нас нет бомб, но мы на цели. Стоим и ждем.
+				b.decision.Detail = "wait"
 				return &domain.UnitCommand{ID: u.ID}
 			}
 			return nil
@@ -210,6 +298,12 @@ func (b *Bot) decideUnitAction(u domain.Unit, suicideMode bool) *domain.UnitComm
 		}
 	}
 
+	// 2.5 РОЛЬ: разведчик идет в туман, охранник к фермерам, охотник к врагам
+	if cmd := b.roleMove(u); cmd != nil {
+		b.decide(PhaseRole, b.roleOf(u.ID), nil, 0)
+		return cmd
+	}
+
 	// 3. БЛУЖДАНИЕ
 	dir, hasDir := b.UnitExploreDirs[u.ID]
 	nextPos := domain.Vec2d{u.Pos.X() + dir.X(), u.Pos.Y() + dir.Y()}
@@ -226,15 +320,45 @@ func (b *Bot) decideUnitAction(u domain.Unit, suicideMode bool) *domain.UnitComm
 		if len(validDirs) > 0 {
 			idx := (b.Tick*13 + int(u.ID[len(u.ID)-1])*7) % len(validDirs)
 			dir = validDirs[idx]
+			// Чаще идем в сторону своей области, чтобы юниты не толпились у точки спавна
+			if preferred, ok := b.preferHeading(u.ID, validDirs); ok && b.Tick%3 != 0 {
+				dir = preferred
+			}
 			b.UnitExploreDirs[u.ID] = dir
 			nextPos = domain.Vec2d{u.Pos.X() + dir.X(), u.Pos.Y() + dir.Y()}
 		} else {
+			b.decide(PhaseIdle, "stuck", nil, 0)
 			return nil
 		}
 	}
+	b.decide(PhaseWander, "", nil, 0)
 	return &domain.UnitCommand{ID: u.ID, Path: []domain.Vec2d{nextPos}}
 }
 
+// bombAndEscape ставит бомбу под юнитом и уводит его из зоны взрыва (и из клеток avoid). ok=false - уйти некуда.
+func (b *Bot) bombAndEscape(u domain.Unit, avoid map[domain.Vec2d]bool, suicideMode bool) (*domain.UnitCommand, bool) {
+	// Защита переживет собственный взрыв - можно бомбить без пути отхода
+	if b.outlastsOwnBomb(u) {
+		b.simulateLocalBomb(u.Pos)
+		b.cleanMemory()
+		return &domain.UnitCommand{ID: u.ID, Bombs: []domain.Vec2d{u.Pos}}, true
+	}
+
+	escapePath, isSafe := b.getBlastSafePathAvoiding(u.Pos, avoid)
+	if !isSafe && !suicideMode {
+		return nil, false
+	}
+	b.simulateLocalBomb(u.Pos)
+	b.cleanMemory()
+
+	cmd := domain.UnitCommand{ID: u.ID, Bombs: []domain.Vec2d{u.Pos}}
+	if isSafe && !suicideMode && len(escapePath) > 1 {
+		cmd.Path = escapePath[1:]
+		b.Escapes[u.ID] = escapePath
+	}
+	return &cmd, true
+}
+
 func (b *Bot) simulateLocalBomb(pos domain.Vec2d) {
 	b.setTile(pos, TileBomb)
 	dirs := []domain.Vec2d{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
@@ -280,20 +404,36 @@ func (b *Bot) scanArea(pos domain.Vec2d) {
 }
 
 func (b *Bot) evaluatePos(pos domain.Vec2d) int {
-	score := 0
+	return b.evaluateParts(pos).total()
+}
+
+// evaluateParts - оценка клетки по составляющим, чтобы роли могли взвешивать их по-своему
+func (b *Bot) evaluateParts(pos domain.Vec2d) scoreParts {
+	var p scoreParts
 	tile := b.Grid[pos.X()][pos.Y()]
-	if tile == TileBox { score += 10 }
+	if tile == TileBox { p.Base += 10 }
 	for _, e := range b.State.Enemies {
-		if b.isInBombLine(e.Pos, pos) { score += 15 } // Снизили с 50 до 15
+		if !killableAt(e.SafeTime, b.BombDelay) { continue } // неуязвим к моменту взрыва
+		if b.isInBombLine(b.enemyPosAtBlast(e), pos) { p.Enemies += 15 } // Снизили с 50 до 15
 	}
 	if count := b.countObstaclesInBlast(pos); count > 0 { 
 		// Квадратичная зависимость: чем больше ящиков, тем несоразмерно выше очков
 		// 1 box = 12
 		// 2 boxes = 48
 		// 3 boxes = 108
-		score += count * count * 12 
+		p.Boxes += count * count * 12 
 	}
-	return score
+	// Мобы: 10 очков за убийство по предсказанной траектории, минус риск быть пойманным
+	p.Mobs, p.Risk = b.mobHuntParts(pos)
+	return p
+}
+
+// enemyPosAtBlast - где будет враг к взрыву бомбы, поставленной сейчас (по трекеру)
+func (b *Bot) enemyPosAtBlast(e domain.EnemyUnit) domain.Vec2d {
+	if pos, ok := b.predictEnemyPos(e.ID, float64(b.BombDelay)/1000); ok {
+		return pos
+	}
+	return e.Pos
 }
 
 func (b *Bot) cleanMemory() {
@@ -303,7 +443,7 @@ func (b *Bot) cleanMemory() {
 			delete(b.MemoryTargets, pos)
 			continue
 		}
-		if b.evaluatePos(pos) == 0 {
+		if b.evaluatePos(pos) <= 0 {
 			delete(b.MemoryTargets, pos)
 		}
 	}
@@ -328,7 +468,14 @@ func (b *Bot) pickBestFromMemory(myPos domain.Vec2d, myID string) *domain.Vec2d
 		// Removed the check that set dist=1 if dist=0. 
 		// We want to prefer the tile we are standing on (dist=0).
 		
-		finalScore := float64(memScore*10) - float64(dist)
+		// Разведчик отвлекается только на выгодные цели рядом, остальное время изучает туман
+		if b.roleOf(myID) == RoleScout && memScore < scoutMinTarget && dist > scoutTargetDist { continue }
+
+		finalScore := b.roleScore(pos, myID)*10 - float64(dist)
+		// Чужая область - за этой целью, скорее всего, уже идет сосед
+		if owner := b.regionOf(pos); owner != "" && owner != myID && b.staysInRegion(myID) {
+			finalScore -= foreignTargetPenalty
+		}
 
 		// Hysteresis: slightly prefer the current target to avoid jitter when scores are close/equal
 		if currentTarget != nil && *currentTarget == pos {
@@ -347,6 +494,7 @@ func (b *Bot) pickBestFromMemory(myPos domain.Vec2d, myID string) *domain.Vec2d
 // ... Grid & Helpers ...
 
 func (b *Bot) initGrid() {
+	b.Detonation = make(map[domain.Vec2d]float64)
 	w, h := b.State.MapSize.X(), b.State.MapSize.Y()
 	b.Grid = make([][]int, w)
 	for x := 0; x < w; x++ { b.Grid[x] = make([]int, h) }
@@ -404,6 +552,7 @@ func (b *Bot) calculateEffectiveTimers() map[domain.Vec2d]float64 {
 func (b *Bot) markDanger(bomb domain.Bomb, timer float64) {
 	isCritical := timer <= 3.0
 	if isCritical { b.setDanger(bomb.Pos) }
+	b.noteDetonation(bomb.Pos, timer)
 	
 	dirs := []domain.Vec2d{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
 	for _, d := range dirs {
@@ -412,6 +561,7 @@ func (b *Bot) markDanger(bomb domain.Bomb, timer float64) {
 			if !b.isValid(pos) { break }
 			tile := b.Grid[pos.X()][pos.Y()]
 			if tile == TileWall { break }
+			b.noteDetonation(pos, timer)
 			if tile == TileBox || tile == TileBomb {
 				if isCritical { b.setDanger(pos) }
 				break
@@ -421,6 +571,11 @@ func (b *Bot) markDanger(bomb domain.Bomb, timer float64) {
 	}
 }
 
+// noteDetonation запоминает, через сколько секунд клетку накроет взрывом (минимум по всем бомбам)
+func (b *Bot) noteDetonation(p domain.Vec2d, timer float64) {
+	if old, ok := b.Detonation[p]; !ok || timer < old { b.Detonation[p] = timer }
+}
+
 func (b *Bot) setTile(p domain.Vec2d, val int) { if b.isValid(p) { b.Grid[p.X()][p.Y()] = val } }
 func (b *Bot) setDanger(p domain.Vec2d) { if b.isValid(p) && b.Grid[p.X()][p.Y()] != TileWall { b.Grid[p.X()][p.Y()] = TileDanger } }
 func (b *Bot) isValid(p domain.Vec2d) bool { return p.X() >= 0 && p.Y() >= 0 && p.X() < b.State.MapSize.X() && p.Y() < b.State.MapSize.Y() }
@@ -480,8 +635,14 @@ func (b *Bot) findSafePath(start domain.Vec2d) []domain.Vec2d {
 }
 
 func (b *Bot) getBlastSafePath(pos domain.Vec2d) ([]domain.Vec2d, bool) {
+	return b.getBlastSafePathAvoiding(pos, nil)
+}
+
+// getBlastSafePathAvoiding - то же, но дополнительно уходим из клеток avoid (взрыв всей цепочки и т.п.)
+func (b *Bot) getBlastSafePathAvoiding(pos domain.Vec2d, avoid map[domain.Vec2d]bool) ([]domain.Vec2d, bool) {
 	unsafe := make(map[domain.Vec2d]bool)
 	unsafe[pos] = true
+	for p := range avoid { unsafe[p] = true }
 	dirs := []domain.Vec2d{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
 	for _, d := range dirs {
 		for i := 1; i <= b.BombRange; i++ {
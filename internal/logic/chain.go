@@ -0,0 +1,317 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+	"sort"
+)
+
+const (
+	chainMinLength   = 4  // Demolition Master: цепочка минимум из 4 бомб
+	chainMaxLength   = 6  // дальше перебор слишком дорогой
+	chainCandidates  = 40 // сколько лучших клеток рассматриваем
+	chainUnitReach   = 20 // глубина BFS для юнитов
+	chainFuseMarginS = 1.0
+	chainSearchLimit = 5000 // сколько цепочек максимум оцениваем за тик
+)
+
+// ChainPlan - цепочка бомб, которые подрывают друг друга и каждая ломает хотя бы одно препятствие
+type ChainPlan struct {
+	Bombs     []domain.Vec2d          `json:"bombs"` // в порядке цепочки
+	Units     map[string]domain.Vec2d `json:"units"` // unitID -> клетка бомбы
+	Obstacles []domain.Vec2d          `json:"obstacles"`
+	Until     int                     `json:"until"`
+
+	cover  map[domain.Vec2d]bool
+	placed map[domain.Vec2d]bool
+	hits   map[domain.Vec2d][]domain.Vec2d // бомба -> препятствия, которые она должна сломать
+}
+
+// ChainStats - прогресс к достижению Demolition Master (показывается в viz)
+type ChainStats struct {
+	Planned       int  `json:"planned"`
+	Executed      int  `json:"executed"`       // все бомбы цепочки выставлены
+	Detonated     int  `json:"detonated"`      // цепочка сработала одним взрывом
+	BestLength    int  `json:"best_length"`    // самая длинная сработавшая цепочка
+	BestObstacles int  `json:"best_obstacles"` // больше всего сломанных препятствий одной цепочкой
+	EachHitBox    bool `json:"each_hit_box"`   // была цепочка, где каждая бомба сломала свое препятствие
+	Achieved      bool `json:"achieved"`
+}
+
+// planChain ведет активную цепочку или ищет новую, если свободных юнитов хватает
+func (b *Bot) planChain(units []domain.Unit) {
+	if b.Chain != nil {
+		b.trackChain()
+		return
+	}
+
+	free := []*trapUnit{}
+	for _, u := range units {
+		if u.BombCount > 0 && b.Orders[u.ID] == nil {
+			free = append(free, &trapUnit{unit: u, dist: b.bfsDistances(u.Pos, chainUnitReach)})
+		}
+	}
+	if len(free) < chainMinLength {
+		return
+	}
+
+	plan := b.searchChain(free)
+	if plan == nil {
+		return
+	}
+	b.Chain = plan
+	b.ChainStats.Planned++
+	for unitID, pos := range plan.Units {
+		b.setOrder(unitID, &UnitOrder{Kind: OrderChain, Target: pos, Until: plan.Until, Avoid: plan.cover})
+	}
+}
+
+// trackChain отмечает выставленные бомбы и ловит момент, когда цепочка взорвалась
+func (b *Bot) trackChain() {
+	c := b.Chain
+	onMap := make(map[domain.Vec2d]bool, len(b.State.Arena.Bombs))
+	for _, bomb := range b.State.Arena.Bombs {
+		onMap[bomb.Pos] = true
+	}
+
+	wasPlaced := len(c.placed)
+	gone := 0
+	for _, pos := range c.Bombs {
+		if onMap[pos] {
+			c.placed[pos] = true
+		} else if c.placed[pos] {
+			gone++
+		}
+	}
+	if wasPlaced < len(c.Bombs) && len(c.placed) == len(c.Bombs) {
+		b.ChainStats.Executed++
+	}
+
+	if len(c.placed) == len(c.Bombs) && gone == len(c.Bombs) {
+		obstacles := make(map[domain.Vec2d]bool, len(b.State.Arena.Obstacles))
+		for _, o := range b.State.Arena.Obstacles {
+			obstacles[o] = true
+		}
+		// Сломано - только то, что пропало на глазах: вне обзора препятствия просто не видно
+		destroyed := make(map[domain.Vec2d]bool, len(c.Obstacles))
+		for _, o := range c.Obstacles {
+			if !obstacles[o] && b.SeenNow(o) {
+				destroyed[o] = true
+			}
+		}
+		b.ChainStats.Detonated++
+		b.ChainStats.BestLength = max(b.ChainStats.BestLength, len(c.Bombs))
+		b.ChainStats.BestObstacles = max(b.ChainStats.BestObstacles, len(destroyed))
+		if eachBombDestroyed(c.Bombs, c.hits, destroyed) {
+			b.ChainStats.EachHitBox = true
+			if len(c.Bombs) >= chainMinLength {
+				b.ChainStats.Achieved = true
+			}
+		}
+		b.Chain = nil
+		return
+	}
+
+	// Не успели выставить всю цепочку - бросаем, уже стоящие бомбы взорвутся сами
+	if b.Tick > c.Until && len(c.placed) < len(c.Bombs) {
+		b.clearOrders(OrderChain)
+		b.Chain = nil
+	}
+}
+
+// searchChain перебирает цепочки по клеткам рядом с препятствиями и раздает бомбы юнитам так,
+// чтобы последняя встала раньше, чем взорвется первая
+func (b *Bot) searchChain(free []*trapUnit) *ChainPlan {
+	type cand struct {
+		pos   domain.Vec2d
+		boxes int
+	}
+	reachable := make(map[domain.Vec2d]bool)
+	for _, tu := range free {
+		for pos := range tu.dist {
+			reachable[pos] = true
+		}
+	}
+	cands := []cand{}
+	for pos := range reachable {
+		if n := b.countObstaclesInBlast(pos); n > 0 && b.Grid[pos.X()][pos.Y()] != TileDanger {
+			cands = append(cands, cand{pos, n})
+		}
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		if cands[i].boxes != cands[j].boxes {
+			return cands[i].boxes > cands[j].boxes
+		}
+		return cands[i].pos[0]*1000+cands[i].pos[1] < cands[j].pos[0]*1000+cands[j].pos[1]
+	})
+	if len(cands) > chainCandidates {
+		cands = cands[:chainCandidates]
+	}
+
+	adj := make(map[domain.Vec2d][]domain.Vec2d, len(cands))
+	for _, a := range cands {
+		for _, c := range cands {
+			if a.pos != c.pos && b.isInBombLine(c.pos, a.pos) {
+				adj[a.pos] = append(adj[a.pos], c.pos)
+			}
+		}
+	}
+
+	var best *ChainPlan
+	bestScore, evaluated := 0, 0
+	chain := make([]domain.Vec2d, 0, chainMaxLength)
+	inChain := make(map[domain.Vec2d]bool)
+
+	var dfs func(pos domain.Vec2d)
+	dfs = func(pos domain.Vec2d) {
+		chain = append(chain, pos)
+		inChain[pos] = true
+		defer func() {
+			chain = chain[:len(chain)-1]
+			delete(inChain, pos)
+		}()
+
+		if len(chain) >= chainMinLength {
+			evaluated++
+			if plan, score := b.evaluateChain(chain, free); plan != nil && score > bestScore {
+				best, bestScore = plan, score
+			}
+		}
+		if len(chain) == chainMaxLength || len(chain) >= len(free) || evaluated >= chainSearchLimit {
+			return
+		}
+		for _, n := range adj[pos] {
+			if !inChain[n] {
+				dfs(n)
+			}
+		}
+	}
+	for _, c := range cands {
+		dfs(c.pos)
+	}
+	return best
+}
+
+// evaluateChain проверяет цепочку (каждая бомба ломает препятствие, все успевают до первого взрыва)
+// и возвращает план и число разрушенных препятствий
+func (b *Bot) evaluateChain(chain []domain.Vec2d, free []*trapUnit) (*ChainPlan, int) {
+	planned := make(map[domain.Vec2d]bool, len(chain))
+	for _, p := range chain {
+		planned[p] = true
+	}
+	obstacles := make(map[domain.Vec2d]bool)
+	hits := make(map[domain.Vec2d][]domain.Vec2d, len(chain))
+	for _, p := range chain {
+		hit := b.obstaclesInBlastWith(p, planned)
+		if len(hit) == 0 {
+			return nil, 0
+		}
+		hits[p] = hit
+		for _, o := range hit {
+			obstacles[o] = true
+		}
+	}
+
+	units := make(map[string]domain.Vec2d, len(chain))
+	used := make(map[string]bool, len(chain))
+	first, last := math.MaxFloat64, 0.0
+	for _, p := range chain {
+		bestID, bestT := "", math.MaxFloat64
+		for _, tu := range free {
+			if used[tu.unit.ID] {
+				continue
+			}
+			if d, ok := tu.dist[p]; ok {
+				if t := b.travelSeconds(d); t < bestT {
+					bestID, bestT = tu.unit.ID, t
+				}
+			}
+		}
+		if bestID == "" {
+			return nil, 0
+		}
+		used[bestID] = true
+		units[bestID] = p
+		first = math.Min(first, bestT)
+		last = math.Max(last, bestT)
+	}
+	if last-first > float64(b.BombDelay)/1000-chainFuseMarginS {
+		return nil, 0
+	}
+
+	cover, together := b.plannedBlast(chain)
+	if !together {
+		return nil, 0
+	}
+	list := make([]domain.Vec2d, 0, len(obstacles))
+	for o := range obstacles {
+		list = append(list, o)
+	}
+	plan := &ChainPlan{
+		Bombs:     append([]domain.Vec2d(nil), chain...),
+		Units:     units,
+		Obstacles: list,
+		Until:     b.Tick + b.secondsToTicks(last) + 3,
+		cover:     cover,
+		placed:    make(map[domain.Vec2d]bool),
+		hits:      hits,
+	}
+	return plan, len(obstacles)
+}
+
+// eachBombDestroyed - можно ли каждой бомбе приписать свое сломанное препятствие (doc.md: каждая бомба
+// цепочки должна сломать хотя бы одно). Одно препятствие засчитывается одной бомбе - паросочетание Куна.
+func eachBombDestroyed(bombs []domain.Vec2d, hits map[domain.Vec2d][]domain.Vec2d, destroyed map[domain.Vec2d]bool) bool {
+	owner := make(map[domain.Vec2d]domain.Vec2d, len(destroyed))
+	var assign func(bomb domain.Vec2d, tried map[domain.Vec2d]bool) bool
+	assign = func(bomb domain.Vec2d, tried map[domain.Vec2d]bool) bool {
+		for _, o := range hits[bomb] {
+			if !destroyed[o] || tried[o] {
+				continue
+			}
+			tried[o] = true
+			if prev, taken := owner[o]; !taken || assign(prev, tried) {
+				owner[o] = bomb
+				return true
+			}
+		}
+		return false
+	}
+	for _, bomb := range bombs {
+		if !assign(bomb, map[domain.Vec2d]bool{}) {
+			return false
+		}
+	}
+	return true
+}
+
+// obstaclesInBlastWith - препятствия, которые сломает бомба в pos, если рядом стоят бомбы planned
+func (b *Bot) obstaclesInBlastWith(pos domain.Vec2d, planned map[domain.Vec2d]bool) []domain.Vec2d {
+	hit := []domain.Vec2d{}
+	dirs := []domain.Vec2d{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+	for _, d := range dirs {
+		for i := 1; i <= b.BombRange; i++ {
+			p := domain.Vec2d{pos.X() + d.X()*i, pos.Y() + d.Y()*i}
+			if !b.isValid(p) || planned[p] {
+				break
+			}
+			t := b.Grid[p.X()][p.Y()]
+			if t == TileBox {
+				hit = append(hit, p)
+				break
+			}
+			if t == TileWall || t == TileBomb {
+				break
+			}
+		}
+	}
+	return hit
+}
+
+// ChainOverlay - текущая цепочка и прогресс достижения для viz
+func (b *Bot) ChainOverlay() map[string]any {
+	return map[string]any{
+		"plan":  b.Chain,
+		"stats": b.ChainStats,
+	}
+}
@@ -0,0 +1,224 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"sync"
+)
+
+// trackStallTicks - сколько тиков юнит может не продвигаться по пути, прежде чем мы решим,
+// что команда потерялась (сервер ее отбросил или путь сбросила бомба)
+const trackStallTicks = 3
+
+// trackedPath - последняя принятая команда юнита и докуда он по ней дошел
+type trackedPath struct {
+	path     []domain.Vec2d
+	bombs    map[domain.Vec2d]bool
+	progress int  // индекс в path клетки, где юнит стоял в последний раз (-1 - еще на старте)
+	stalled  int  // тиков без продвижения
+	moving   bool // сервер сказал, что юнит еще идет: новую команду он сейчас не примет
+}
+
+// remaining - непройденная часть пути
+func (t *trackedPath) remaining() []domain.Vec2d { return t.path[t.progress+1:] }
+
+// CommandTracker помнит принятые пути юнитов и не дает слать одно и то же каждый тик:
+// пока юнит идет, новую команду на движение сервер все равно не примет, а лимит запросов общий.
+type CommandTracker struct {
+	mu     sync.Mutex // Filter и Accepted зовутся из разных горутин конвейера
+	tracks map[string]*trackedPath
+	pos    map[string]domain.Vec2d // где юниты стояли на последнем снимке
+
+	sent    int // команд юнитам отправлено
+	skipped int // команд пропущено как повторы
+	hazards int // принятых путей забыто из-за опасности на них
+}
+
+// Counts - сколько команд отправлено, пропущено как повторы и сколько путей забыто из-за опасности
+func (ct *CommandTracker) Counts() (sent, skipped, hazards int) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.sent, ct.skipped, ct.hazards
+}
+
+func NewCommandTracker() *CommandTracker {
+	return &CommandTracker{tracks: make(map[string]*trackedPath), pos: make(map[string]domain.Vec2d)}
+}
+
+// Observe сверяет продвижение юнитов по принятым путям с настоящим снимком.
+// Спроецированный снимок сюда не передавать: его позиции взяты из самих путей, и прогресс убежал бы вперед юнитов.
+func (ct *CommandTracker) Observe(state *domain.GameState) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.observe(state)
+}
+
+// Filter оставляет только команды, которые что-то меняют; прогресс по путям к этому моменту сверен Observe.
+// hazard - опасен ли остаток пути: тот же путь от опасности не уведет, и если юнит готов принять команду,
+// путь забываем - со следующего тика планировщик пойдет от настоящей клетки юнита и предложит другой. nil - команд нет.
+func (ct *CommandTracker) Filter(cmd *domain.PlayerCommand, hazard func(path []domain.Vec2d) bool) *domain.PlayerCommand {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	if cmd == nil {
+		return nil
+	}
+
+	out := domain.PlayerCommand{}
+	for _, c := range cmd.Bombers {
+		if len(c.Path) == 0 && len(c.Bombs) == 0 {
+			ct.skipped++ // "стоять" сервер не умеет - нечего и слать
+			continue
+		}
+		t := ct.tracks[c.ID]
+		if t != nil && samePlan(t, c) {
+			ct.skipped++
+			if !t.moving && hazard != nil && hazard(t.remaining()) {
+				delete(ct.tracks, c.ID)
+				ct.hazards++
+			}
+			continue
+		}
+		out.Bombers = append(out.Bombers, c)
+	}
+	if len(out.Bombers) == 0 {
+		return nil
+	}
+	return &out
+}
+
+// Accepted запоминает команды, которые сервер принял
+func (ct *CommandTracker) Accepted(cmd domain.PlayerCommand) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	for _, c := range cmd.Bombers {
+		ct.sent++
+		if len(c.Path) == 0 {
+			delete(ct.tracks, c.ID)
+			continue
+		}
+		bombs := make(map[domain.Vec2d]bool, len(c.Bombs))
+		for _, b := range c.Bombs {
+			if pos, ok := ct.pos[c.ID]; !ok || b != pos { // бомба под ногами ставится сразу
+				bombs[b] = true
+			}
+		}
+		ct.tracks[c.ID] = &trackedPath{path: append([]domain.Vec2d(nil), c.Path...), bombs: bombs, progress: -1}
+	}
+}
+
+// InFlight - непройденная часть принятого пути юнита (nil - юнит ничего не выполняет)
+func (ct *CommandTracker) InFlight(id string) []domain.Vec2d {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	if t := ct.tracks[id]; t != nil {
+		return append([]domain.Vec2d(nil), t.remaining()...)
+	}
+	return nil
+}
+
+// Reset забывает все пути и обнуляет счетчики (новый раунд)
+func (ct *CommandTracker) Reset() {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.tracks = make(map[string]*trackedPath)
+	ct.pos = make(map[string]domain.Vec2d)
+	ct.sent, ct.skipped, ct.hazards = 0, 0, 0
+}
+
+// observe сдвигает прогресс по путям; дошедших, погибших и сбитых с пути забываем
+func (ct *CommandTracker) observe(state *domain.GameState) {
+	units := make(map[string]domain.Unit, len(state.MyUnits))
+	for _, u := range state.MyUnits {
+		units[u.ID] = u
+		ct.pos[u.ID] = u.Pos
+	}
+	for id, t := range ct.tracks {
+		u, ok := units[id]
+		if !ok || !u.Alive {
+			delete(ct.tracks, id)
+			continue
+		}
+		if u.CanMove != nil && *u.CanMove {
+			delete(ct.tracks, id) // сервер говорит, что юнит свободен - старый путь закончен
+			continue
+		}
+		idx := -2
+		if t.progress == -1 && len(t.path) > 0 {
+			idx = -1 // пока не видели юнит на пути - считаем, что он на старте
+		}
+		for i := t.progress + 1; i < len(t.path); i++ {
+			if t.path[i] == u.Pos {
+				idx = i
+				break
+			}
+		}
+		if idx == -2 && t.progress >= 0 && t.path[t.progress] == u.Pos {
+			idx = t.progress
+		}
+		switch {
+		case idx == len(t.path)-1:
+			delete(ct.tracks, id) // дошел
+		case idx == -2:
+			delete(ct.tracks, id) // сбит с пути: бомба на дороге или сервер отбросил команду
+		case idx == t.progress && u.CanMove == nil && t.stalled >= trackStallTicks:
+			delete(ct.tracks, id) // стоит на месте - путь, видимо, сброшен
+		default:
+			if idx == t.progress {
+				t.stalled++
+			} else {
+				t.stalled = 0
+			}
+			t.progress = idx
+			t.moving = u.Moving()
+			rest := make(map[domain.Vec2d]bool, len(t.remaining()))
+			for _, p := range t.remaining() {
+				rest[p] = true
+			}
+			for b := range t.bombs {
+				if !rest[b] {
+					delete(t.bombs, b) // уже поставлена
+				}
+			}
+		}
+	}
+}
+
+// samePlan - новая команда ничего не добавляет к принятой: ее путь - хвост непройденного остатка
+// (планировщик идет от спроецированной позиции, а она на этом остатке впереди настоящей),
+// а ее бомбы юнит и так поставит. Не попросить бомбу можно только на срезанном начале остатка - там юнит уже в пути.
+func samePlan(t *trackedPath, c domain.UnitCommand) bool {
+	rest := t.remaining()
+	skip := len(rest) - len(c.Path)
+	if skip < 0 {
+		return false
+	}
+	ahead := make(map[domain.Vec2d]bool, len(c.Path))
+	for i, p := range c.Path {
+		if rest[skip+i] != p {
+			return false
+		}
+		ahead[p] = true
+	}
+	wanted := make(map[domain.Vec2d]bool, len(c.Bombs))
+	for _, b := range c.Bombs {
+		if !t.bombs[b] {
+			return false
+		}
+		wanted[b] = true
+	}
+	for b := range t.bombs {
+		if ahead[b] && !wanted[b] {
+			return false
+		}
+	}
+	return true
+}
+
+// PathHazard - проходит ли путь через клетки, которые скоро накроет взрыв
+func (b *Bot) PathHazard(path []domain.Vec2d) bool {
+	for _, p := range path {
+		if b.isTileDangerous(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+	"sort"
+)
+
+// TargetView - цель из памяти для отладочного слоя
+type TargetView struct {
+	Pos   domain.Vec2d `json:"pos"`
+	Score int          `json:"score"`
+	Unit  string       `json:"unit,omitempty"` // кто за ней идет (AssignedTargets)
+}
+
+// CellValue - число в клетке: секунды до взрыва, штраф за мобов
+type CellValue struct {
+	Pos   domain.Vec2d `json:"pos"`
+	Value float64      `json:"v"`
+}
+
+// DebugView - внутренности планировщика на этом тике для отладочных слоев viz
+type DebugView struct {
+	Targets    []TargetView              `json:"targets"`
+	Escapes    map[string][]domain.Vec2d `json:"escapes"`
+	Detonation []CellValue               `json:"detonation"` // секунд до взрыва
+	MobRisk    []CellValue               `json:"mob_risk"`   // штраф к оценке цели за риск попасться мобу
+}
+
+// DebugOverlay собирает отладочные слои. Списки отсортированы, чтобы поток viz не слал одинаковое как изменения.
+func (b *Bot) DebugOverlay() DebugView {
+	v := DebugView{Escapes: b.Escapes}
+	if b.State == nil || b.Grid == nil {
+		return v
+	}
+	for p, score := range b.MemoryTargets {
+		v.Targets = append(v.Targets, TargetView{Pos: p, Score: score, Unit: b.AssignedTargets[p]})
+	}
+	for p, unit := range b.AssignedTargets {
+		if _, ok := b.MemoryTargets[p]; !ok {
+			v.Targets = append(v.Targets, TargetView{Pos: p, Unit: unit}) // цель уже стерта из памяти, а юнит еще идет
+		}
+	}
+	sort.Slice(v.Targets, func(i, j int) bool { return cellBefore(v.Targets[i].Pos, v.Targets[j].Pos) })
+
+	for p, t := range b.Detonation {
+		v.Detonation = append(v.Detonation, CellValue{p, math.Round(t*10) / 10})
+	}
+	sortCells(v.Detonation)
+
+	// Штраф тот же, что вычитает evaluatePos (mobHuntParts, с приманкой для призраков).
+	// Считаем только рядом с мобами: за горизонт риска моб дальше не дойдет (клетка в секунду).
+	reach := mobRiskHorizonS + 1
+	checked := map[domain.Vec2d]bool{}
+	for _, m := range b.State.Mobs {
+		for dx := -reach; dx <= reach; dx++ {
+			for dy := abs(dx) - reach; dy <= reach-abs(dx); dy++ {
+				p := domain.Vec2d{m.Pos.X() + dx, m.Pos.Y() + dy}
+				if checked[p] || !b.isValid(p) {
+					continue
+				}
+				checked[p] = true
+				if _, penalty := b.mobHuntParts(p); penalty > 0 {
+					v.MobRisk = append(v.MobRisk, CellValue{p, float64(penalty)})
+				}
+			}
+		}
+	}
+	sortCells(v.MobRisk)
+	return v
+}
+
+// cellBefore - порядок клеток по столбцам, затем по строкам
+func cellBefore(a, b domain.Vec2d) bool {
+	if a.X() != b.X() {
+		return a.X() < b.X()
+	}
+	return a.Y() < b.Y()
+}
+
+func sortCells(cells []CellValue) {
+	sort.Slice(cells, func(i, j int) bool { return cellBefore(cells[i].Pos, cells[j].Pos) })
+}
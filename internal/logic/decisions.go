@@ -0,0 +1,54 @@
+package logic
+
+import "gorutin/internal/domain"
+
+// Фазы decideUnitAction - на какой из них юнит получил команду
+const (
+	PhaseIdle     = "idle"     // команды нет
+	PhaseSurvival = "survival" // уходит из зоны взрыва
+	PhaseOrder    = "order"    // тактическое задание (ловушка, цепочка)
+	PhaseTarget   = "target"   // цель из памяти
+	PhaseRole     = "role"     // ход по роли
+	PhaseWander   = "wander"   // блуждание
+)
+
+// UnitDecision - почему юнит получил свою команду на этом тике
+type UnitDecision struct {
+	Unit   string        `json:"unit"`
+	Phase  string        `json:"phase"`
+	Detail string        `json:"detail,omitempty"` // тип задания, роль или что делаем на цели
+	Target *domain.Vec2d `json:"target,omitempty"` // куда идем (nil - цели нет)
+	Score  int           `json:"score,omitempty"`  // оценка цели из памяти (0 - не из памяти)
+	Path   int           `json:"path"`             // длина отправленного пути
+	Bombs  int           `json:"bombs"`            // сколько бомб ставим
+}
+
+// decide отмечает фазу решения текущего юнита
+func (b *Bot) decide(phase, detail string, target *domain.Vec2d, score int) {
+	b.decision.Phase, b.decision.Detail, b.decision.Score = phase, detail, score
+	b.decision.Target = nil
+	if target != nil {
+		t := *target
+		b.decision.Target = &t
+	}
+}
+
+// finishDecisions дописывает в решения то, что ушло после validateTeam
+func (b *Bot) finishDecisions(commands []domain.UnitCommand) {
+	byID := make(map[string]domain.UnitCommand, len(commands))
+	for _, c := range commands {
+		byID[c.ID] = c
+	}
+	for i := range b.Decisions {
+		d := &b.Decisions[i]
+		c, ok := byID[d.Unit]
+		if !ok {
+			continue // снята validateTeam или решения не было: фаза объясняет почему
+		}
+		d.Path, d.Bombs = len(c.Path), len(c.Bombs)
+		if d.Target == nil && len(c.Path) > 0 {
+			t := c.Path[len(c.Path)-1]
+			d.Target = &t
+		}
+	}
+}
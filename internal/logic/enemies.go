@@ -0,0 +1,223 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+	"time"
+)
+
+const (
+	enemyHistoryLen    = 32
+	enemySpeedWindow   = 5 * time.Second // окно для оценки скорости
+	enemyForgetAfter   = 30 * time.Second
+	enemyDestHorizon   = 3.0 // секунд вперед для "текущей цели" врага
+	enemyPredictSteps  = 4   // дальше прямой экстраполяции не верим: враг успеет свернуть
+	enemySteadyMoves   = 3   // сколько шагов в окне нужно, чтобы судить о направлении
+	enemySteadyShare   = 0.75
+	spawnClusterRadius = 2
+	spawnClusterWindow = 5 * time.Second // неуязвимость после возрождения (doc.md)
+)
+
+// EnemySighting - одно наблюдение врага
+type EnemySighting struct {
+	Pos  domain.Vec2d
+	Tick int
+	At   time.Time
+}
+
+// EnemyTrack - история врага по его ID
+type EnemyTrack struct {
+	ID          string
+	History     []EnemySighting
+	Speed       float64      // клеток в секунду по последним наблюдениям
+	Dir         domain.Vec2d // основное направление движения
+	Destination domain.Vec2d // куда он идет (экстраполяция на enemyDestHorizon)
+	Steady      bool         // в окне хватает шагов и почти все они вдоль Dir - экстраполяции можно верить
+	SafeTime    int
+	Visible     bool
+	LastSeen    time.Time
+	Team        int // 0 - команда неизвестна
+}
+
+// Pos - последняя известная позиция
+func (t *EnemyTrack) Pos() domain.Vec2d { return t.History[len(t.History)-1].Pos }
+
+type spawnCluster struct {
+	center domain.Vec2d
+	team   int
+	at     time.Time
+}
+
+// EnemyTracker хранит историю вражеских юнитов между тиками
+type EnemyTracker struct {
+	Tracks   map[string]*EnemyTrack
+	clusters []spawnCluster
+	nextTeam int
+}
+
+func NewEnemyTracker() *EnemyTracker {
+	return &EnemyTracker{Tracks: make(map[string]*EnemyTrack), nextTeam: 1}
+}
+
+// Update добавляет наблюдения текущего тика; невидимые враги помечаются как "последний раз видели"
+func (et *EnemyTracker) Update(enemies []domain.EnemyUnit, tick int, now time.Time) {
+	seen := make(map[string]bool, len(enemies))
+	for _, e := range enemies {
+		seen[e.ID] = true
+		t, ok := et.Tracks[e.ID]
+		if !ok {
+			t = &EnemyTrack{ID: e.ID}
+			et.Tracks[e.ID] = t
+			if e.SafeTime > 0 {
+				// Только что возродился: вся команда появляется в одной точке
+				t.Team = et.teamForSpawn(e.Pos, now)
+			}
+		}
+		t.History = append(t.History, EnemySighting{Pos: e.Pos, Tick: tick, At: now})
+		if len(t.History) > enemyHistoryLen {
+			t.History = t.History[len(t.History)-enemyHistoryLen:]
+		}
+		t.SafeTime = e.SafeTime
+		t.Visible = true
+		t.LastSeen = now
+		t.estimate()
+	}
+
+	for id, t := range et.Tracks {
+		if seen[id] {
+			continue
+		}
+		t.Visible = false
+		if now.Sub(t.LastSeen) > enemyForgetAfter {
+			delete(et.Tracks, id)
+		}
+	}
+}
+
+// teamForSpawn относит свежезаспавненного врага к кластеру возрождения
+func (et *EnemyTracker) teamForSpawn(pos domain.Vec2d, now time.Time) int {
+	kept := et.clusters[:0]
+	for _, c := range et.clusters {
+		if now.Sub(c.at) <= spawnClusterWindow {
+			kept = append(kept, c)
+		}
+	}
+	et.clusters = kept
+
+	for _, c := range et.clusters {
+		if abs(c.center.X()-pos.X())+abs(c.center.Y()-pos.Y()) <= spawnClusterRadius {
+			return c.team
+		}
+	}
+	team := et.nextTeam
+	et.nextTeam++
+	et.clusters = append(et.clusters, spawnCluster{center: pos, team: team, at: now})
+	return team
+}
+
+// estimate пересчитывает скорость, направление и цель по истории
+func (t *EnemyTrack) estimate() {
+	last := t.History[len(t.History)-1]
+	first := last
+	dist := 0
+	moves := []domain.Vec2d{}
+	for i := len(t.History) - 1; i > 0; i-- {
+		prev := t.History[i-1]
+		if last.At.Sub(prev.At) > enemySpeedWindow {
+			break
+		}
+		cur := t.History[i]
+		dx, dy := cur.Pos.X()-prev.Pos.X(), cur.Pos.Y()-prev.Pos.Y()
+		if dx != 0 || dy != 0 {
+			moves = append(moves, domain.Vec2d{dx, dy})
+		}
+		dist += abs(dx) + abs(dy)
+		first = prev
+	}
+
+	elapsed := last.At.Sub(first.At).Seconds()
+	if elapsed > 0 {
+		t.Speed = float64(dist) / elapsed
+	} else {
+		t.Speed = 0
+	}
+
+	dx, dy := last.Pos.X()-first.Pos.X(), last.Pos.Y()-first.Pos.Y()
+	switch {
+	case dx == 0 && dy == 0:
+		t.Dir = domain.Vec2d{}
+	case abs(dx) >= abs(dy):
+		t.Dir = domain.Vec2d{sign(dx), 0}
+	default:
+		t.Dir = domain.Vec2d{0, sign(dy)}
+	}
+
+	along := 0
+	for _, m := range moves {
+		if m.X()*t.Dir.X()+m.Y()*t.Dir.Y() > 0 {
+			along++
+		}
+	}
+	t.Steady = len(moves) >= enemySteadyMoves && float64(along) >= enemySteadyShare*float64(len(moves))
+
+	steps := int(math.Round(t.Speed * enemyDestHorizon))
+	t.Destination = domain.Vec2d{last.Pos.X() + t.Dir.X()*steps, last.Pos.Y() + t.Dir.Y()*steps}
+}
+
+// predictEnemyPos - где будет враг через seconds секунд: идем по его направлению, пока клетки проходимы.
+// Не дальше enemyPredictSteps клеток, а если направление неустойчиво - враг остается на месте.
+func (b *Bot) predictEnemyPos(id string, seconds float64) (domain.Vec2d, bool) {
+	t, ok := b.Enemies.Tracks[id]
+	if !ok {
+		return domain.Vec2d{}, false
+	}
+	pos := t.Pos()
+	if !t.Steady {
+		return pos, true
+	}
+	steps := min(int(math.Round(t.Speed*seconds)), enemyPredictSteps)
+	for i := 0; i < steps && t.Dir != (domain.Vec2d{}); i++ {
+		next := domain.Vec2d{pos.X() + t.Dir.X(), pos.Y() + t.Dir.Y()}
+		if !b.isValid(next) {
+			break
+		}
+		if tile := b.Grid[next.X()][next.Y()]; tile == TileWall || tile == TileBox || tile == TileBomb {
+			break
+		}
+		pos = next
+	}
+	return pos, true
+}
+
+// EnemyView - враг для оверлея визуализации
+type EnemyView struct {
+	ID        string       `json:"id"`
+	Pos       domain.Vec2d `json:"pos"`
+	Predicted domain.Vec2d `json:"predicted"`
+	Speed     float64      `json:"speed"`
+	Team      int          `json:"team"`
+	Visible   bool         `json:"visible"`
+	LastSeen  float64      `json:"last_seen"` // секунд назад
+}
+
+// EnemyOverlay отдает треки врагов с предсказанием на момент взрыва нашей бомбы
+func (b *Bot) EnemyOverlay() []EnemyView {
+	if b.State == nil || b.Grid == nil {
+		return nil
+	}
+	views := make([]EnemyView, 0, len(b.Enemies.Tracks))
+	now := b.now()
+	for id, t := range b.Enemies.Tracks {
+		pred, _ := b.predictEnemyPos(id, float64(b.BombDelay)/1000)
+		views = append(views, EnemyView{
+			ID:        id,
+			Pos:       t.Pos(),
+			Predicted: pred,
+			Speed:     t.Speed,
+			Team:      t.Team,
+			Visible:   t.Visible,
+			LastSeen:  now.Sub(t.LastSeen).Seconds(),
+		})
+	}
+	return views
+}
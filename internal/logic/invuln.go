@@ -0,0 +1,27 @@
+package logic
+
+import "gorutin/internal/domain"
+
+// invulnMarginS - запас на задержку сети и тик сервера, чтобы не умереть на последних миллисекундах защиты
+const invulnMarginS = 0.5
+
+// safeSeconds - сколько еще юнит неуязвим после возрождения
+func safeSeconds(u domain.Unit) float64 { return float64(u.SafeTime) / 1000 }
+
+// invulnerableAt - переживет ли юнит взрыв, который накроет pos (защита кончится позже взрыва)
+func (b *Bot) invulnerableAt(u domain.Unit, pos domain.Vec2d) bool {
+	if u.SafeTime <= 0 {
+		return false
+	}
+	timer, ok := b.Detonation[pos]
+	if !ok {
+		// Опасность без таймера - бомба, поставленная союзником на этом тике (simulateLocalBomb): рванет через полный фитиль
+		timer = float64(b.BombDelay) / 1000
+	}
+	return safeSeconds(u) > timer+invulnMarginS
+}
+
+// outlastsOwnBomb - неуязвимость продлится дольше фитиля: свою бомбу можно ставить и не убегать
+func (b *Bot) outlastsOwnBomb(u domain.Unit) bool {
+	return safeSeconds(u) > float64(b.BombDelay)/1000+invulnMarginS
+}
@@ -0,0 +1,221 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+)
+
+const (
+	mobKillScore    = 120  // 10 очков за моба; в масштабе evaluatePos (1 ящик = 12)
+	mobRiskPenalty  = 150  // штраф за шанс попасться мобу, пока ставим бомбу
+	mobVisionGhost  = 10   // радиус обзора призрака (doc.md)
+	mobTrackTTL     = 20   // сколько тиков помним моба, ушедшего из вида
+	mobDecayPatrol  = 0.85 // патрульный может сменить направление в любой момент
+	mobDecayGhost   = 0.95 // призрак целеустремленный, предсказывается надежнее
+	mobRiskHorizonS = 2    // секунды, за которые юнит успевает поставить бомбу и уйти
+)
+
+// MobTrack - что мы знаем о мобе между тиками
+type MobTrack struct {
+	ID       string
+	Type     string
+	Pos      domain.Vec2d
+	Dir      domain.Vec2d // последнее наблюдаемое направление движения
+	SafeTime int          // мс до пробуждения/уязвимости
+	LastTick int
+}
+
+func (t *MobTrack) isGhost() bool { return t.Type == "ghost" }
+
+// updateMobTracks обновляет историю мобов и кеширует их траектории на этот тик
+func (b *Bot) updateMobTracks() {
+	for _, m := range b.State.Mobs {
+		t, ok := b.MobTracks[m.ID]
+		if !ok {
+			t = &MobTrack{ID: m.ID, Pos: m.Pos}
+			b.MobTracks[m.ID] = t
+		} else if m.Pos != t.Pos {
+			t.Dir = domain.Vec2d{sign(m.Pos.X() - t.Pos.X()), sign(m.Pos.Y() - t.Pos.Y())}
+			if t.Dir.X() != 0 && t.Dir.Y() != 0 {
+				// Пропустили тик и моб повернул - оставляем только основную ось
+				t.Dir = domain.Vec2d{t.Dir.X(), 0}
+			}
+		}
+		t.Type = m.Type
+		t.Pos = m.Pos
+		t.SafeTime = m.SafeTime
+		t.LastTick = b.Tick
+	}
+	for id, t := range b.MobTracks {
+		if b.Tick-t.LastTick > mobTrackTTL {
+			delete(b.MobTracks, id)
+		}
+	}
+
+	b.mobPaths = make(map[string][]domain.Vec2d, len(b.State.Mobs))
+	for _, m := range b.State.Mobs {
+		b.mobPaths[m.ID] = b.predictMobPath(b.MobTracks[m.ID], b.fuseSeconds(), nil)
+	}
+}
+
+// fuseSeconds - время до взрыва нашей бомбы в целых секундах (шаг предсказания)
+func (b *Bot) fuseSeconds() int {
+	return int(math.Ceil(float64(b.BombDelay) / 1000))
+}
+
+// killableAt - будет ли цель уязвима к моменту взрыва бомбы, поставленной сейчас
+func killableAt(safeTimeMs, fuseMs int) bool { return safeTimeMs < fuseMs }
+
+// predictMobPath возвращает позиции моба на каждую секунду: [0] - текущая, [n] - через n секунд.
+// lure - клетка, на которую призрак пойдет вместо ближайшего юнита (юнит стоит там и ставит бомбу).
+func (b *Bot) predictMobPath(t *MobTrack, seconds int, lure *domain.Vec2d) []domain.Vec2d {
+	path := make([]domain.Vec2d, 0, seconds+1)
+	pos, dir := t.Pos, t.Dir
+	path = append(path, pos)
+	sleep := int(math.Ceil(float64(t.SafeTime) / 1000))
+
+	for s := 1; s <= seconds; s++ {
+		if s <= sleep {
+			path = append(path, pos)
+			continue
+		}
+		if t.isGhost() {
+			if target, ok := b.ghostTarget(pos, lure); ok {
+				pos = stepToward(pos, target)
+				path = append(path, pos)
+				continue
+			}
+		}
+		next := domain.Vec2d{pos.X() + dir.X(), pos.Y() + dir.Y()}
+		if dir == (domain.Vec2d{}) || !b.isMobPassable(next, t.isGhost()) {
+			dir = b.turnMob(pos, dir, t.isGhost())
+			next = domain.Vec2d{pos.X() + dir.X(), pos.Y() + dir.Y()}
+		}
+		if b.isMobPassable(next, t.isGhost()) {
+			pos = next
+		}
+		path = append(path, pos)
+	}
+	return path
+}
+
+// ghostTarget - за кем погонится призрак: приманка или ближайший наш юнит в радиусе обзора
+func (b *Bot) ghostTarget(from domain.Vec2d, lure *domain.Vec2d) (domain.Vec2d, bool) {
+	if lure != nil && inRadius(from, *lure, mobVisionGhost) {
+		return *lure, true
+	}
+	best, bestDist, found := domain.Vec2d{}, math.MaxInt, false
+	for _, u := range b.State.MyUnits {
+		if !u.Alive || !inRadius(from, u.Pos, mobVisionGhost) {
+			continue
+		}
+		if d := b.manhattan(from, u.Pos); d < bestDist {
+			best, bestDist, found = u.Pos, d, true
+		}
+	}
+	return best, found
+}
+
+// turnMob выбирает новое направление, когда старое уперлось (по часовой стрелке от текущего)
+func (b *Bot) turnMob(pos, dir domain.Vec2d, ghost bool) domain.Vec2d {
+	turns := []domain.Vec2d{{-dir.Y(), dir.X()}, {dir.Y(), -dir.X()}, {-dir.X(), -dir.Y()}}
+	if dir == (domain.Vec2d{}) {
+		turns = []domain.Vec2d{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	}
+	for _, d := range turns {
+		if b.isMobPassable(domain.Vec2d{pos.X() + d.X(), pos.Y() + d.Y()}, ghost) {
+			return d
+		}
+	}
+	return domain.Vec2d{}
+}
+
+func (b *Bot) isMobPassable(p domain.Vec2d, ghost bool) bool {
+	if !b.isValid(p) {
+		return false
+	}
+	t := b.Grid[p.X()][p.Y()]
+	if ghost {
+		return t != TileWall
+	}
+	return t != TileWall && t != TileBox && t != TileBomb
+}
+
+// mobHuntParts - ожидаемая ценность убийства мобов бомбой в pos и риск быть пойманным (оба >= 0)
+func (b *Bot) mobHuntParts(pos domain.Vec2d) (gain, penalty int) {
+	fuse := b.fuseSeconds()
+	ev, risk := 0.0, 0.0
+
+	for _, m := range b.State.Mobs {
+		t := b.MobTracks[m.ID]
+		if t == nil {
+			continue
+		}
+		path := b.mobPaths[m.ID]
+		decay := mobDecayPatrol
+		if t.isGhost() {
+			decay = mobDecayGhost
+			if inRadius(t.Pos, pos, mobVisionGhost) {
+				// Юнит на pos становится приманкой: призрак идет за ним в зону взрыва
+				path = b.predictMobPath(t, fuse, &pos)
+			}
+		}
+
+		for s := 0; s <= mobRiskHorizonS && s < len(path); s++ {
+			if b.manhattan(path[s], pos) <= 1 {
+				risk += math.Pow(decay, float64(s))
+				break
+			}
+		}
+
+		if !killableAt(t.SafeTime, b.BombDelay) || len(path) <= fuse {
+			continue
+		}
+		if b.isInBombLine(path[fuse], pos) {
+			ev += math.Pow(decay, float64(fuse))
+		}
+	}
+	return int(mobKillScore * ev), int(mobRiskPenalty * risk)
+}
+
+// addMobHuntTargets добавляет в память клетки, из которых бомба накроет моба в момент взрыва
+func (b *Bot) addMobHuntTargets() {
+	fuse := b.fuseSeconds()
+	dirs := []domain.Vec2d{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+	for _, m := range b.State.Mobs {
+		path := b.mobPaths[m.ID]
+		if !killableAt(m.SafeTime, b.BombDelay) || len(path) <= fuse {
+			continue
+		}
+		at := path[fuse]
+		candidates := []domain.Vec2d{at}
+		for _, d := range dirs {
+			for i := 1; i <= b.BombRange; i++ {
+				candidates = append(candidates, domain.Vec2d{at.X() + d.X()*i, at.Y() + d.Y()*i})
+			}
+		}
+		for _, c := range candidates {
+			if b.isWalkable(c) {
+				if score := b.evaluatePos(c); score > 0 {
+					b.MemoryTargets[c] = score
+				}
+			}
+		}
+	}
+}
+
+func stepToward(from, to domain.Vec2d) domain.Vec2d {
+	dx, dy := to.X()-from.X(), to.Y()-from.Y()
+	if abs(dx) >= abs(dy) && dx != 0 {
+		return domain.Vec2d{from.X() + sign(dx), from.Y()}
+	}
+	if dy != 0 {
+		return domain.Vec2d{from.X(), from.Y() + sign(dy)}
+	}
+	return from
+}
+
+func inRadius(a, c domain.Vec2d, r int) bool {
+	dx, dy := a.X()-c.X(), a.Y()-c.Y()
+	return dx*dx+dy*dy <= r*r
+}
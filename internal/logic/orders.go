@@ -0,0 +1,56 @@
+package logic
+
+import "gorutin/internal/domain"
+
+const (
+	OrderTrap  = "trap"
+	OrderChain = "chain"
+)
+
+// UnitOrder - задание юниту от тактического модуля: дойти до клетки и поставить там бомбу
+type UnitOrder struct {
+	Kind   string
+	Target domain.Vec2d
+	Until  int                   // тик, после которого задание протухает
+	Ref    string                // к чему относится (ID врага и т.п.)
+	Avoid  map[domain.Vec2d]bool // куда не убегать после установки (общий взрыв цепочки)
+}
+
+// setOrder выдает задание, снимая с юнита обычную цель
+func (b *Bot) setOrder(unitID string, order *UnitOrder) {
+	b.releaseTarget(unitID)
+	b.Orders[unitID] = order
+}
+
+// clearOrders снимает задания заданного типа (модули пересчитывают их каждый тик)
+func (b *Bot) clearOrders(kind string) {
+	for id, o := range b.Orders {
+		if o.Kind == kind {
+			delete(b.Orders, id)
+		}
+	}
+}
+
+// executeOrder ведет юнит к клетке задания и ставит бомбу. nil - задание невыполнимо, действуем как обычно.
+func (b *Bot) executeOrder(u domain.Unit, order *UnitOrder, suicideMode bool) *domain.UnitCommand {
+	if b.Tick > order.Until || u.BombCount == 0 {
+		delete(b.Orders, u.ID)
+		return nil
+	}
+
+	if u.Pos == order.Target {
+		cmd, ok := b.bombAndEscape(u, order.Avoid, suicideMode)
+		delete(b.Orders, u.ID)
+		if !ok {
+			return nil
+		}
+		return cmd
+	}
+
+	path := b.bfsPath(u.Pos, order.Target)
+	if len(path) < 2 {
+		delete(b.Orders, u.ID)
+		return nil
+	}
+	return &domain.UnitCommand{ID: u.ID, Path: path[1:]}
+}
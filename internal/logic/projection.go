@@ -0,0 +1,40 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"time"
+)
+
+// ProjectState - копия снимка, сдвинутая вперед на lead: к тому моменту, когда наши команды
+// дойдут до сервера, таймеры бомб и неуязвимости успеют уменьшиться, а юниты - пройти часть своих путей.
+// inFlight - непройденный остаток принятого пути юнита (см. CommandTracker.InFlight).
+// Врагов и мобов не двигаем: их предсказывают трекеры бота.
+func ProjectState(state *domain.GameState, lead time.Duration, speed int, inFlight func(id string) []domain.Vec2d) *domain.GameState {
+	if state == nil || lead <= 0 {
+		return state
+	}
+	projected := *state
+	sec := lead.Seconds()
+
+	projected.Arena.Bombs = make([]domain.Bomb, len(state.Arena.Bombs))
+	for i, bomb := range state.Arena.Bombs {
+		bomb.Timer -= sec
+		if bomb.Timer < 0 {
+			bomb.Timer = 0 // вот-вот взорвется; сама бомба еще на карте
+		}
+		projected.Arena.Bombs[i] = bomb
+	}
+
+	projected.MyUnits = make([]domain.Unit, len(state.MyUnits))
+	steps := int(sec * float64(speed))
+	for i, u := range state.MyUnits {
+		u.SafeTime = max(0, u.SafeTime-int(lead.Milliseconds()))
+		if steps > 0 && u.Alive && inFlight != nil {
+			if path := inFlight(u.ID); len(path) > 0 {
+				u.Pos = path[min(steps, len(path))-1]
+			}
+		}
+		projected.MyUnits[i] = u
+	}
+	return &projected
+}
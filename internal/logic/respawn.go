@@ -0,0 +1,100 @@
+package logic
+
+import "time"
+
+const (
+	respawnPenalty     = 0.10             // штраф за возрождение: 10% текущих очков (doc.md)
+	respawnInvulnS     = 5.0              // неуязвимость после возрождения
+	respawnInvulnBonus = 0.5              // насколько продуктивнее играем без страха взрывов
+	respawnSetupS      = 3.0              // пока новые юниты добегут до препятствий
+	scoreRateWindow    = 90 * time.Second // окно оценки очков в секунду
+	defaultUnitRate    = 0.05             // очков в секунду на юнита, пока нет истории
+)
+
+type scoreSample struct {
+	at    time.Time
+	score int
+	alive int
+}
+
+// RespawnDecision - сравнение "доигрывать одним юнитом" против "умереть и возродиться"
+type RespawnDecision struct {
+	Sacrifice bool    `json:"sacrifice"`
+	PlayOn    float64 `json:"play_on"` // ожидаемые очки до конца раунда
+	Respawn   float64 `json:"respawn"`
+	Remaining float64 `json:"remaining"` // секунд до конца раунда
+	UnitRate  float64 `json:"unit_rate"`
+	Known     bool    `json:"known"` // известно ли время конца раунда
+}
+
+// SetRoundWindow сообщает боту начало и конец текущего раунда (по /api/rounds)
+func (b *Bot) SetRoundWindow(start, end time.Time) {
+	b.RoundStart = start
+	b.RoundEnd = end
+}
+
+// recordScore копит историю очков для оценки темпа
+func (b *Bot) recordScore(now time.Time, alive int) {
+	b.scoreHistory = append(b.scoreHistory, scoreSample{at: now, score: b.State.RawScore, alive: alive})
+	cut := 0
+	for cut < len(b.scoreHistory) && now.Sub(b.scoreHistory[cut].at) > scoreRateWindow {
+		cut++
+	}
+	b.scoreHistory = b.scoreHistory[cut:]
+}
+
+// unitScoreRate - очков в секунду на одного живого юнита за последние scoreRateWindow
+func (b *Bot) unitScoreRate() float64 {
+	if len(b.scoreHistory) < 2 {
+		return defaultUnitRate
+	}
+	gained, unitSeconds := 0, 0.0
+	for i := 1; i < len(b.scoreHistory); i++ {
+		prev, cur := b.scoreHistory[i-1], b.scoreHistory[i]
+		if d := cur.score - prev.score; d > 0 {
+			gained += d // штрафы за возрождение в темп не включаем
+		}
+		unitSeconds += cur.at.Sub(prev.at).Seconds() * float64(prev.alive)
+	}
+	if unitSeconds <= 0 || gained == 0 {
+		return defaultUnitRate
+	}
+	return float64(gained) / unitSeconds
+}
+
+// decideRespawn решает, стоит ли последнему выжившему пожертвовать собой ради возрождения всей команды
+func (b *Bot) decideRespawn(now time.Time, teamSize int) RespawnDecision {
+	rate := b.unitScoreRate()
+	d := RespawnDecision{UnitRate: rate, Known: !b.RoundEnd.IsZero()}
+	if !d.Known {
+		// Не знаем, сколько осталось - ведем себя как раньше
+		d.Sacrifice = true
+		return d
+	}
+
+	remaining := b.RoundEnd.Sub(now).Seconds()
+	if remaining < 0 {
+		remaining = 0
+	}
+	d.Remaining = remaining
+
+	// Чтобы умереть, нужно дождаться собственного взрыва
+	afterDeath := remaining - float64(b.BombDelay)/1000
+	d.PlayOn = rate * remaining
+	if afterDeath > respawnSetupS {
+		productive := afterDeath - respawnSetupS
+		invuln := respawnInvulnS
+		if invuln > productive {
+			invuln = productive
+		}
+		d.Respawn = rate*float64(teamSize)*(productive+invuln*respawnInvulnBonus) -
+			respawnPenalty*float64(b.State.RawScore)
+	} else {
+		d.Respawn = -respawnPenalty * float64(b.State.RawScore)
+	}
+	d.Sacrifice = d.Respawn > d.PlayOn
+	return d
+}
+
+// RespawnOverlay - последнее решение по возрождению для viz
+func (b *Bot) RespawnOverlay() RespawnDecision { return b.Respawn }
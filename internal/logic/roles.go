@@ -0,0 +1,296 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+	"sort"
+)
+
+const (
+	RoleFarmer = "farmer" // ломает препятствия и собирает цепочки
+	RoleHunter = "hunter" // охотится на вражеских юнитов
+	RoleScout  = "scout"  // разведывает туман войны в своей области
+	RoleGuard  = "guard"  // держится рядом с фермерами и отстреливает призраков
+
+	roleRebalanceTicks = 20  // пересматриваем роли примерно раз в 13 секунд
+	roleStickiness     = 3   // юнит сохраняет роль, если кандидат лучше меньше чем на 3 клетки
+	scoutMinTarget     = 48  // разведчика отвлекают только цели от 2 ящиков...
+	scoutTargetDist    = 4   // ...или совсем рядом
+	scoutFogHorizon    = 20  // как далеко разведчик ищет давно не виденные клетки
+	scoutStaleCap      = 100 // тиков; дольше - все равно что никогда не видели
+	scoutMinStale      = 10  // клетки, виденные недавно, разведывать незачем
+	guardKeepDist      = 2   // охранник держится в паре клеток от фермера
+	hunterSearchDepth  = 30
+)
+
+// scoreParts - составляющие оценки клетки в масштабе evaluatePos
+type scoreParts struct {
+	Base    int // ящик под ногами
+	Boxes   int // препятствия в зоне взрыва
+	Enemies int // враги в зоне взрыва к моменту детонации
+	Mobs    int // ожидаемая ценность убийства мобов
+	Risk    int // риск попасться мобу (вычитается)
+}
+
+func (p scoreParts) total() int { return p.Base + p.Boxes + p.Enemies + p.Mobs - p.Risk }
+
+type roleWeights struct{ Boxes, Enemies, Mobs float64 }
+
+var roleWeightTable = map[string]roleWeights{
+	RoleFarmer: {Boxes: 1.5, Enemies: 0.5, Mobs: 0.5},
+	RoleHunter: {Boxes: 0.5, Enemies: 3, Mobs: 1.5},
+	RoleScout:  {Boxes: 1, Enemies: 1, Mobs: 1},
+	RoleGuard:  {Boxes: 0.5, Enemies: 1, Mobs: 3},
+}
+
+// roleOf - текущая роль юнита ("" - еще не назначена)
+func (b *Bot) roleOf(id string) string {
+	if info, ok := b.Units[id]; ok {
+		return info.Role
+	}
+	return ""
+}
+
+// roleScore - ценность клетки с точки зрения роли юнита
+func (b *Bot) roleScore(pos domain.Vec2d, id string) float64 {
+	p := b.evaluateParts(pos)
+	w, ok := roleWeightTable[b.roleOf(id)]
+	if !ok {
+		return float64(p.total())
+	}
+	return float64(p.Base) + w.Boxes*float64(p.Boxes) + w.Enemies*float64(p.Enemies) +
+		w.Mobs*float64(p.Mobs) - float64(p.Risk)
+}
+
+// assignRoles раз в roleRebalanceTicks (или при смене состава) раздает роли по обстановке:
+// охотники - по числу врагов, охранник - если рядом бродят призраки,
+// разведчики - когда видимых препятствий мало. Остальные фермеры, хотя бы один всегда.
+func (b *Bot) assignRoles(units []domain.Unit) {
+	if len(units) == 0 || (b.rolesTick != 0 && b.Tick-b.rolesTick < roleRebalanceTicks) {
+		return
+	}
+	b.rolesTick = b.Tick
+
+	enemies := []domain.Vec2d{}
+	for _, e := range b.State.Enemies {
+		enemies = append(enemies, e.Pos)
+	}
+	ghosts := []domain.Vec2d{}
+	for _, m := range b.State.Mobs {
+		if t := b.MobTracks[m.ID]; t != nil && t.isGhost() {
+			ghosts = append(ghosts, m.Pos)
+		}
+	}
+
+	n := len(units)
+	hunters, guards, scouts := 0, 0, 0
+	if len(enemies) > 0 && b.Strategy.HunterDiv > 0 {
+		hunters = min((len(enemies)+1)/2, n/b.Strategy.HunterDiv)
+	}
+	if len(ghosts) > 0 && n >= 3 {
+		guards = 1
+	}
+	switch {
+	case !b.Strategy.Scouts:
+	case len(b.State.Arena.Obstacles) < 2*n:
+		scouts = max(1, n/3)
+	case n >= 4:
+		scouts = 1
+	}
+	// Минимум один фермер: урезаем разведку, потом охрану, потом охоту
+	for hunters+guards+scouts >= n && hunters+guards+scouts > 0 {
+		switch {
+		case scouts > 0:
+			scouts--
+		case guards > 0:
+			guards--
+		default:
+			hunters--
+		}
+	}
+
+	pool := append([]domain.Unit(nil), units...)
+	take := func(role string, count int, key func(u domain.Unit) int) {
+		if count <= 0 {
+			return
+		}
+		sort.SliceStable(pool, func(i, j int) bool {
+			return b.roleKey(pool[i], role, key) < b.roleKey(pool[j], role, key)
+		})
+		for _, u := range pool[:count] {
+			b.Units[u.ID].Role = role
+		}
+		pool = pool[count:]
+	}
+	take(RoleHunter, hunters, func(u domain.Unit) int { return nearestDist(b, u.Pos, enemies) })
+	take(RoleGuard, guards, func(u domain.Unit) int { return nearestDist(b, u.Pos, ghosts) })
+	// В разведку идут те, у кого рядом меньше всего работы
+	take(RoleScout, scouts, func(u domain.Unit) int { return b.targetsNear(u.Pos, scoutFogHorizon/2) })
+	for _, u := range pool {
+		b.Units[u.ID].Role = RoleFarmer
+	}
+}
+
+// roleKey - ключ сортировки кандидатов на роль; текущая роль дает фору, чтобы юниты не дергались
+func (b *Bot) roleKey(u domain.Unit, role string, key func(u domain.Unit) int) int {
+	k := key(u)
+	if b.roleOf(u.ID) == role {
+		k -= roleStickiness
+	}
+	return k
+}
+
+func nearestDist(b *Bot, from domain.Vec2d, points []domain.Vec2d) int {
+	best := math.MaxInt32
+	for _, p := range points {
+		if d := b.manhattan(from, p); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// targetsNear - сколько целей из памяти в радиусе r
+func (b *Bot) targetsNear(pos domain.Vec2d, r int) int {
+	count := 0
+	for p := range b.MemoryTargets {
+		if b.manhattan(pos, p) <= r {
+			count++
+		}
+	}
+	return count
+}
+
+// updateFog отмечает клетки в обзоре живых юнитов как увиденные на этом тике
+func (b *Bot) updateFog(units []domain.Unit) {
+	w, h := b.State.MapSize.X(), b.State.MapSize.Y()
+	if len(b.LastSeen) != w || (w > 0 && len(b.LastSeen[0]) != h) {
+		b.LastSeen = make([][]int, w)
+		for x := range b.LastSeen {
+			b.LastSeen[x] = make([]int, h)
+		}
+	}
+	for _, u := range units {
+		for dx := -b.View; dx <= b.View; dx++ {
+			for dy := -b.View; dy <= b.View; dy++ {
+				p := domain.Vec2d{u.Pos.X() + dx, u.Pos.Y() + dy}
+				if b.isValid(p) && inRadius(u.Pos, p, b.View) {
+					b.LastSeen[p.X()][p.Y()] = b.Tick
+				}
+			}
+		}
+	}
+}
+
+// SeenNow - видел ли клетку кто-то из наших на последнем тике
+func (b *Bot) SeenNow(p domain.Vec2d) bool {
+	return b.Tick > 0 && b.LastSeen != nil && b.isValid(p) && b.LastSeen[p.X()][p.Y()] == b.Tick
+}
+
+// fogStaleness - сколько тиков клетку никто не видел (не больше scoutStaleCap)
+func (b *Bot) fogStaleness(p domain.Vec2d) int {
+	if b.LastSeen == nil || !b.isValid(p) {
+		return 0
+	}
+	return min(b.Tick-b.LastSeen[p.X()][p.Y()], scoutStaleCap)
+}
+
+// roleMove - ход по роли, когда подходящей цели в памяти нет (nil - обычное блуждание)
+func (b *Bot) roleMove(u domain.Unit) *domain.UnitCommand {
+	switch b.roleOf(u.ID) {
+	case RoleScout:
+		return b.exploreFog(u)
+	case RoleGuard:
+		return b.guardFarmer(u)
+	case RoleHunter:
+		return b.chaseEnemy(u)
+	}
+	return nil
+}
+
+// exploreFog ведет разведчика к самой давно не виденной клетке, предпочитая свою область
+func (b *Bot) exploreFog(u domain.Unit) *domain.UnitCommand {
+	var heading domain.Vec2d
+	if info := b.Units[u.ID]; info != nil {
+		heading = info.Heading
+	}
+	best, bestScore := u.Pos, math.Inf(-1)
+	for c, d := range b.bfsDistances(u.Pos, scoutFogHorizon) {
+		stale := b.fogStaleness(c)
+		if d == 0 || stale < scoutMinStale {
+			continue
+		}
+		// Проекция на направление к центру области (Heading длиной ~10)
+		along := float64((c.X()-u.Pos.X())*heading.X()+(c.Y()-u.Pos.Y())*heading.Y()) / 10
+		score := float64(stale) - 2*float64(d) + along
+		if b.regionOf(c) == u.ID {
+			score += regionOwnBonus
+		}
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	if best == u.Pos {
+		return nil
+	}
+	if path := b.bfsPath(u.Pos, best); len(path) > 1 {
+		return &domain.UnitCommand{ID: u.ID, Path: path[1:]}
+	}
+	return nil
+}
+
+// guardFarmer держит охранника рядом с ближайшим фермером: призраки идут на ближайшего юнита,
+// и охранник с бомбой оказывается у них на пути
+func (b *Bot) guardFarmer(u domain.Unit) *domain.UnitCommand {
+	var farmer *domain.Vec2d
+	for _, other := range b.State.MyUnits {
+		if !other.Alive || other.ID == u.ID || b.roleOf(other.ID) != RoleFarmer {
+			continue
+		}
+		if farmer == nil || b.manhattan(u.Pos, other.Pos) < b.manhattan(u.Pos, *farmer) {
+			pos := other.Pos
+			farmer = &pos
+		}
+	}
+	if farmer == nil {
+		return nil
+	}
+	if b.manhattan(u.Pos, *farmer) <= guardKeepDist {
+		return &domain.UnitCommand{ID: u.ID}
+	}
+	path := b.bfsPath(u.Pos, *farmer)
+	if len(path) <= guardKeepDist+1 {
+		return nil
+	}
+	return &domain.UnitCommand{ID: u.ID, Path: path[1 : len(path)-guardKeepDist]}
+}
+
+// chaseEnemy ведет охотника к месту, где окажется ближайший враг, когда охотник туда добежит
+func (b *Bot) chaseEnemy(u domain.Unit) *domain.UnitCommand {
+	dist := b.bfsDistances(u.Pos, hunterSearchDepth)
+	best, bestScore := u.Pos, math.MaxInt32
+	for id, t := range b.Enemies.Tracks {
+		if !killableAt(t.SafeTime, b.BombDelay) {
+			continue
+		}
+		at := t.Pos()
+		if pred, ok := b.predictEnemyPos(id, b.travelSeconds(b.manhattan(u.Pos, at))); ok {
+			at = pred
+		}
+		if b.manhattan(u.Pos, at) <= b.BombRange+1 {
+			return nil // уже рядом - дальше работают обычные цели
+		}
+		for c, d := range dist {
+			if score := 3*b.manhattan(c, at) + d; score < bestScore {
+				best, bestScore = c, score
+			}
+		}
+	}
+	if best == u.Pos {
+		return nil
+	}
+	if path := b.bfsPath(u.Pos, best); len(path) > 1 {
+		return &domain.UnitCommand{ID: u.ID, Path: path[1:]}
+	}
+	return nil
+}
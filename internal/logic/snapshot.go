@@ -0,0 +1,145 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorutin/internal/domain"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const snapshotVersion = 1
+
+// memoryEntry - цель из MemoryTargets (ключ-массив в JSON-объект не положить)
+type memoryEntry struct {
+	Pos   domain.Vec2d `json:"pos"`
+	Score int          `json:"score"`
+}
+
+// Snapshot - то, что бот узнал за раунд и что жалко терять при перезапуске посреди раунда:
+// память о целях, направления разведки, роли и области, туман войны, статы усилений.
+// Треки врагов и приказы не сохраняем - за время перезапуска они все равно устареют.
+type Snapshot struct {
+	Version int       `json:"version"`
+	Round   string    `json:"round"`
+	SavedAt time.Time `json:"saved_at"`
+
+	Tick      int `json:"tick"`
+	RolesTick int `json:"roles_tick"`
+
+	BombRange int `json:"bomb_range"`
+	BombDelay int `json:"bomb_delay"`
+	Speed     int `json:"speed"`
+	MaxBombs  int `json:"max_bombs"`
+	View      int `json:"view"`
+
+	MemoryTargets   []memoryEntry           `json:"memory_targets"`
+	UnitTargets     map[string]domain.Vec2d `json:"unit_targets"`
+	UnitExploreDirs map[string]domain.Vec2d `json:"unit_explore_dirs"`
+	Units           map[string]*UnitInfo    `json:"units"`
+	LastSeen        [][]int                 `json:"last_seen"`
+	MobTracks       map[string]*MobTrack    `json:"mob_tracks"`
+	ChainStats      ChainStats              `json:"chain_stats"`
+	BoosterPlan     BoosterPlan             `json:"booster_plan"`
+}
+
+// Snapshot снимает состояние бота для раунда round
+func (b *Bot) Snapshot(round string) *Snapshot {
+	s := &Snapshot{
+		Version:         snapshotVersion,
+		Round:           round,
+		SavedAt:         b.now(),
+		Tick:            b.Tick,
+		RolesTick:       b.rolesTick,
+		BombRange:       b.BombRange,
+		BombDelay:       b.BombDelay,
+		Speed:           b.Speed,
+		MaxBombs:        b.MaxBombs,
+		View:            b.View,
+		UnitTargets:     make(map[string]domain.Vec2d, len(b.UnitTargets)),
+		UnitExploreDirs: b.UnitExploreDirs,
+		Units:           b.Units,
+		LastSeen:        b.LastSeen,
+		MobTracks:       b.MobTracks,
+		ChainStats:      b.ChainStats,
+		BoosterPlan:     b.BoosterPlan,
+	}
+	for pos, score := range b.MemoryTargets {
+		s.MemoryTargets = append(s.MemoryTargets, memoryEntry{Pos: pos, Score: score})
+	}
+	for id, t := range b.UnitTargets {
+		if t != nil {
+			s.UnitTargets[id] = *t
+		}
+	}
+	return s
+}
+
+// Restore возвращает боту состояние из снимка; остальное - как после Reset
+func (b *Bot) Restore(s *Snapshot) {
+	b.Reset()
+	b.Tick, b.rolesTick = s.Tick, s.RolesTick
+	b.BombRange, b.BombDelay, b.Speed, b.MaxBombs, b.View = s.BombRange, s.BombDelay, s.Speed, s.MaxBombs, s.View
+	for _, m := range s.MemoryTargets {
+		b.MemoryTargets[m.Pos] = m.Score
+	}
+	for id, t := range s.UnitTargets {
+		t := t
+		b.UnitTargets[id] = &t
+	}
+	if s.UnitExploreDirs != nil {
+		b.UnitExploreDirs = s.UnitExploreDirs
+	}
+	if s.Units != nil {
+		b.Units = s.Units
+	}
+	if s.MobTracks != nil {
+		b.MobTracks = s.MobTracks
+	}
+	b.LastSeen = s.LastSeen
+	b.ChainStats = s.ChainStats
+	b.BoosterPlan = s.BoosterPlan
+}
+
+// SaveSnapshot пишет снимок атомарно: во временный файл и переименованием,
+// чтобы падение посреди записи не оставило битый файл
+func SaveSnapshot(path string, s *Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot читает снимок; (nil, nil) - файла нет
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("%s: snapshot version %d, want %d", path, s.Version, snapshotVersion)
+	}
+	return &s, nil
+}
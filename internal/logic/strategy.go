@@ -0,0 +1,31 @@
+package logic
+
+import "sort"
+
+// Strategy - какие тактики бот пускает в ход; выбирается в конфиге по имени
+type Strategy struct {
+	Name      string
+	Traps     bool // ловушки на вражеских юнитов (planTraps)
+	Chains    bool // цепочки взрывов (planChain)
+	Scouts    bool // разведчики в тумане войны
+	Respawn   bool // последний юнит может пожертвовать собой ради возрождения
+	HunterDiv int  // охотников не больше n/HunterDiv (0 - не охотимся)
+}
+
+const DefaultStrategy = "balanced"
+
+var Strategies = map[string]Strategy{
+	"balanced":   {Name: "balanced", Traps: true, Chains: true, Scouts: true, Respawn: true, HunterDiv: 3},
+	"farm":       {Name: "farm", Chains: true, Scouts: true, Respawn: true},      // только очки за препятствия
+	"aggressive": {Name: "aggressive", Traps: true, Respawn: true, HunterDiv: 2}, // охота вместо цепочек
+}
+
+// StrategyNames - известные стратегии по алфавиту
+func StrategyNames() []string {
+	names := make([]string, 0, len(Strategies))
+	for name := range Strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,275 @@
+package logic
+
+import (
+	"fmt"
+	"gorutin/internal/domain"
+)
+
+const (
+	ffMarginS     = 0.5 // запас по времени вокруг взрыва: тик сервера, задержка сети
+	ffEscapeDepth = 10  // как далеко ищем союзнику отход от новых взрывов
+)
+
+const (
+	FFBlast   = "blast"   // союзник окажется в клетке в момент взрыва
+	FFTrapped = "trapped" // союзник стоит в зоне взрыва, и уйти ему некуда
+	FFBlocked = "blocked" // бомба встанет на пути союзника
+)
+
+// FriendlyFire - бомба, которую валидатор снял с команды, и кого она задела бы
+type FriendlyFire struct {
+	Bomber string       `json:"bomber"`
+	Victim string       `json:"victim"`
+	Bomb   domain.Vec2d `json:"bomb"`
+	Reason string       `json:"reason"`
+}
+
+func (f FriendlyFire) String() string {
+	return fmt.Sprintf("friendly fire: bomb of %s at %v would %s %s", f.Bomber, f.Bomb, map[string]string{
+		FFBlast: "hit", FFTrapped: "trap", FFBlocked: "block",
+	}[f.Reason], f.Victim)
+}
+
+// teamBomb - бомба из команд этого тика: где и через сколько секунд юнит ее поставит
+type teamBomb struct {
+	pos   domain.Vec2d
+	owner string
+	at    float64
+}
+
+// unitRoute - маршрут юнита по командам этого тика: клетка i достигается через i/Speed секунд
+type unitRoute struct {
+	unit  domain.Unit
+	cells []domain.Vec2d
+}
+
+// validateTeam проверяет бомбы всех команд вместе: getBlastSafePath заботится только о самом
+// бомбере, а взрыв может накрыть союзника на его маршруте, отрезать ему отход или перегородить путь.
+// Бомбы принимаются по одной; бомба, после которой кто-то из союзников гибнет, снимается
+// (юнит все равно идет по своему пути). Союзники, которым угрожали и без новых бомб, не учитываются.
+func (b *Bot) validateTeam(units []domain.Unit, commands []domain.UnitCommand, suicideMode bool) []domain.UnitCommand {
+	b.FriendlyFire = b.FriendlyFire[:0]
+	routes := make([]unitRoute, 0, len(units))
+	routeOf := make(map[string][]domain.Vec2d, len(units))
+	cmdOf := make(map[string]*domain.UnitCommand, len(commands))
+	for i := range commands {
+		cmdOf[commands[i].ID] = &commands[i]
+	}
+	for _, u := range units {
+		cells := []domain.Vec2d{u.Pos}
+		if cmd := cmdOf[u.ID]; cmd != nil {
+			cells = append(cells, cmd.Path...)
+		}
+		routes = append(routes, unitRoute{unit: u, cells: cells})
+		routeOf[u.ID] = cells
+	}
+
+	already := make(map[string]bool)
+	for _, r := range routes {
+		if reason := b.routeHazard(r, nil, nil); reason != "" {
+			already[r.unit.ID] = true
+		}
+	}
+
+	accepted := []teamBomb{}
+	for i := range commands {
+		cmd := &commands[i]
+		if len(cmd.Bombs) == 0 {
+			continue
+		}
+		kept := make([]domain.Vec2d, 0, len(cmd.Bombs))
+		for _, p := range cmd.Bombs {
+			cand := teamBomb{pos: p, owner: cmd.ID, at: b.arrivalSeconds(routeOf[cmd.ID], p)}
+			trial := append(append([]teamBomb(nil), accepted...), cand)
+			if victim, reason := b.teamHazard(routes, trial, already, suicideMode); victim != "" {
+				b.FriendlyFire = append(b.FriendlyFire, FriendlyFire{Bomber: cmd.ID, Victim: victim, Bomb: p, Reason: reason})
+				continue
+			}
+			accepted = append(accepted, cand)
+			kept = append(kept, p)
+		}
+		cmd.Bombs = kept
+	}
+	return commands
+}
+
+// teamHazard - первый союзник, которому угрожают бомбы bombs ("" - все целы)
+func (b *Bot) teamHazard(routes []unitRoute, bombs []teamBomb, already map[string]bool, suicideMode bool) (string, string) {
+	blast := b.teamBlast(bombs)
+	placed := make(map[domain.Vec2d]bool, len(bombs))
+	for _, bomb := range bombs {
+		placed[bomb.pos] = true
+	}
+	for _, r := range routes {
+		if already[r.unit.ID] {
+			continue
+		}
+		// Последний выживший сам ищет смерти ради возрождения команды
+		if suicideMode {
+			continue
+		}
+		for _, bomb := range bombs {
+			if bomb.owner == r.unit.ID {
+				continue
+			}
+			for i, c := range r.cells {
+				if c == bomb.pos && b.travelSeconds(i) > bomb.at {
+					return r.unit.ID, FFBlocked
+				}
+			}
+		}
+		if reason := b.routeHazard(r, blast, placed); reason != "" {
+			return r.unit.ID, reason
+		}
+	}
+	return "", ""
+}
+
+// routeHazard - погибнет ли юнит на своем маршруте от новых взрывов blast (nil - только от уже стоящих бомб).
+// Клетки placed заняты новыми бомбами, сквозь них не уйти.
+func (b *Bot) routeHazard(r unitRoute, blast map[domain.Vec2d]float64, placed map[domain.Vec2d]bool) string {
+	at := func(c domain.Vec2d) (float64, bool) {
+		t, ok := b.Detonation[c]
+		if nt, nok := blast[c]; nok && (!ok || nt < t) {
+			t, ok = nt, true
+		}
+		return t, ok
+	}
+	safe := safeSeconds(r.unit)
+
+	last := len(r.cells) - 1
+	for i, c := range r.cells[:last] {
+		t, ok := at(c)
+		if !ok || safe > t+ffMarginS {
+			continue
+		}
+		if t >= b.travelSeconds(i)-ffMarginS && t <= b.travelSeconds(i+1)+ffMarginS {
+			return FFBlast
+		}
+	}
+
+	// В конце маршрута юнит стоит, пока бот не уведет его; нужно, чтобы уводить было куда
+	end, arrive := r.cells[last], b.travelSeconds(last)
+	t, ok := at(end)
+	if !ok || safe > t+ffMarginS || t < arrive-ffMarginS {
+		return ""
+	}
+	if !b.canEscape(end, arrive, at, placed) {
+		return FFTrapped
+	}
+	return ""
+}
+
+// canEscape - есть ли из from (куда юнит придет через start секунд) путь из зоны взрывов,
+// по которому каждую клетку он проходит раньше, чем ее накроет
+func (b *Bot) canEscape(from domain.Vec2d, start float64, at func(domain.Vec2d) (float64, bool), placed map[domain.Vec2d]bool) bool {
+	dist := map[domain.Vec2d]int{from: 0}
+	queue := []domain.Vec2d{from}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		if _, danger := at(curr); !danger {
+			return true
+		}
+		if dist[curr] >= ffEscapeDepth {
+			continue
+		}
+		for _, n := range b.neighbors(curr) {
+			if _, seen := dist[n]; seen || placed[n] || !b.isWalkable(n) {
+				continue
+			}
+			arrive := start + b.travelSeconds(dist[curr]+1)
+			if t, ok := at(n); ok && t <= arrive+ffMarginS {
+				continue
+			}
+			dist[n] = dist[curr] + 1
+			queue = append(queue, n)
+		}
+	}
+	return false
+}
+
+// teamBlast - когда новые бомбы накроют клетки (секунды от текущего момента), с учетом цепочек:
+// новая бомба в зоне уже тикающей взрывается вместе с ней, бомбы друг друга подрывают
+func (b *Bot) teamBlast(bombs []teamBomb) map[domain.Vec2d]float64 {
+	if len(bombs) == 0 {
+		return nil
+	}
+	fuse := float64(b.BombDelay) / 1000
+	when := make([]float64, len(bombs))
+	index := make(map[domain.Vec2d]int, len(bombs))
+	for i, bomb := range bombs {
+		when[i] = bomb.at + fuse
+		if t, ok := b.Detonation[bomb.pos]; ok && t < when[i] {
+			when[i] = t
+		}
+		index[bomb.pos] = i
+	}
+
+	onMap := make(map[domain.Vec2d]int, len(b.State.Arena.Bombs))
+	for _, bomb := range b.State.Arena.Bombs {
+		onMap[bomb.Pos] = bomb.Radius
+	}
+
+	blast := make(map[domain.Vec2d]float64)
+	cover := func(cells []domain.Vec2d, t float64) {
+		for _, c := range cells {
+			if old, ok := blast[c]; !ok || t < old {
+				blast[c] = t
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for i, bomb := range bombs {
+			cells := b.bombRay(bomb.pos, b.BombRange, index)
+			cover(cells, when[i])
+			for _, c := range cells[1:] {
+				if j, ok := index[c]; ok && when[i] < when[j] {
+					when[j] = when[i]
+					changed = true
+				}
+				// Уже стоящая бомба сдетонирует раньше своего таймера
+				if radius, ok := onMap[c]; ok {
+					if t, known := b.Detonation[c]; !known || when[i] < t {
+						cover(b.bombRay(c, radius, index), when[i])
+					}
+				}
+			}
+		}
+	}
+	return blast
+}
+
+// bombRay - клетки взрыва бомбы в pos (первая - сама pos); planned - другие бомбы этого тика, они гасят луч
+func (b *Bot) bombRay(pos domain.Vec2d, radius int, planned map[domain.Vec2d]int) []domain.Vec2d {
+	cells := []domain.Vec2d{pos}
+	dirs := []domain.Vec2d{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+	for _, d := range dirs {
+		for i := 1; i <= radius; i++ {
+			c := domain.Vec2d{pos.X() + d.X()*i, pos.Y() + d.Y()*i}
+			if !b.isValid(c) {
+				break
+			}
+			t := b.Grid[c.X()][c.Y()]
+			if t == TileWall {
+				break
+			}
+			cells = append(cells, c)
+			if _, ok := planned[c]; ok || t == TileBox || t == TileBomb {
+				break
+			}
+		}
+	}
+	return cells
+}
+
+// arrivalSeconds - через сколько секунд юнит дойдет до клетки p своего маршрута
+func (b *Bot) arrivalSeconds(route []domain.Vec2d, p domain.Vec2d) float64 {
+	for i, c := range route {
+		if c == p {
+			return b.travelSeconds(i)
+		}
+	}
+	return 0
+}
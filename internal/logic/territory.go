@@ -0,0 +1,198 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+)
+
+const (
+	foreignTargetPenalty = 100 // в масштабе pickBestFromMemory: примерно половина цели на 1 ящик
+	regionOwnBonus       = 10  // разведчику: своя область важнее чужой
+)
+
+// partitionTerritory делит достижимую карту между живыми юнитами. У каждого юнита есть якорь,
+// область - клетки, до которых от его якоря ближе, чем от чужих (BFS сразу от всех якорей).
+// Якорь нового юнита ставится как можно дальше от остальных, потом каждый тик сдвигается
+// к центру своей области (шаг Ллойда), и области выравниваются. Делить от позиций самих юнитов
+// бесполезно: у спавна они стоят кучей, и почти вся карта достается крайнему.
+func (b *Bot) partitionTerritory(units []domain.Unit) {
+	w, h := b.State.MapSize.X(), b.State.MapSize.Y()
+	if len(units) == 0 {
+		b.Regions = nil
+		return
+	}
+
+	starts := make([]domain.Vec2d, len(units))
+	for i, u := range units {
+		starts[i] = u.Pos
+	}
+	reach := b.multiBFS(starts)
+
+	// Якоря, которые стали недостижимы (бомба, завал), ставим заново
+	anchors := make([]domain.Vec2d, 0, len(units))
+	fresh := []int{}
+	for i, u := range units {
+		info := b.Units[u.ID]
+		if info == nil || info.Anchor == nil {
+			fresh = append(fresh, i)
+			continue
+		}
+		if _, ok := reach[*info.Anchor]; !ok {
+			info.Anchor = nil
+			fresh = append(fresh, i)
+			continue
+		}
+		anchors = append(anchors, *info.Anchor)
+	}
+	for _, i := range fresh {
+		a := b.farthestCell(reach, anchors)
+		anchors = append(anchors, a)
+		if info := b.Units[units[i].ID]; info != nil {
+			info.Anchor = &a
+		}
+	}
+
+	owners := b.assignRegions(units)
+
+	type regionSum struct{ cells, x, y int }
+	sums := make([]regionSum, len(units))
+	for c, i := range owners {
+		sums[i].cells++
+		sums[i].x += c.X()
+		sums[i].y += c.Y()
+	}
+	// Шаг Ллойда: якорь - клетка своей области, ближайшая к ее центру
+	best := make([]int, len(units))
+	moved := make([]domain.Vec2d, len(units))
+	for i := range best {
+		best[i] = math.MaxInt32
+	}
+	for c, i := range owners {
+		s := sums[i]
+		cx, cy := s.x/s.cells, s.y/s.cells
+		if d := abs(c.X()-cx) + abs(c.Y()-cy); d < best[i] {
+			best[i], moved[i] = d, c
+		}
+	}
+	for i, u := range units {
+		if info := b.Units[u.ID]; info != nil && sums[i].cells > 0 {
+			a := moved[i]
+			info.Anchor = &a
+		}
+	}
+
+	owners = b.assignRegions(units)
+	b.Regions = make([][]string, w)
+	for x := range b.Regions {
+		b.Regions[x] = make([]string, h)
+	}
+	counts := make([]int, len(units))
+	for c, i := range owners {
+		b.Regions[c.X()][c.Y()] = units[i].ID
+		counts[i]++
+	}
+
+	// Направление блуждания - к своему якорю
+	for i, u := range units {
+		info := b.Units[u.ID]
+		if info == nil {
+			continue
+		}
+		info.Region, info.Heading = counts[i], domain.Vec2d{}
+		if info.Anchor == nil {
+			continue
+		}
+		dx := float64(info.Anchor.X() - u.Pos.X())
+		dy := float64(info.Anchor.Y() - u.Pos.Y())
+		if l := math.Hypot(dx, dy); l >= 1 {
+			info.Heading = domain.Vec2d{int(math.Round(dx / l * 10)), int(math.Round(dy / l * 10))}
+		}
+	}
+}
+
+// assignRegions - BFS сразу от всех якорей: клетка -> индекс юнита в units
+func (b *Bot) assignRegions(units []domain.Unit) map[domain.Vec2d]int {
+	starts := []domain.Vec2d{}
+	idx := []int{}
+	for i, u := range units {
+		if info := b.Units[u.ID]; info != nil && info.Anchor != nil {
+			starts = append(starts, *info.Anchor)
+			idx = append(idx, i)
+		}
+	}
+	owners := b.multiBFS(starts)
+	for c, k := range owners {
+		owners[c] = idx[k]
+	}
+	return owners
+}
+
+// multiBFS - BFS по проходимым клеткам сразу от нескольких стартов: клетка -> индекс ближайшего старта
+func (b *Bot) multiBFS(starts []domain.Vec2d) map[domain.Vec2d]int {
+	owner := make(map[domain.Vec2d]int)
+	queue := []domain.Vec2d{}
+	for i, s := range starts {
+		if _, taken := owner[s]; !taken && b.isValid(s) {
+			owner[s] = i
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		for _, n := range b.neighbors(curr) {
+			if _, seen := owner[n]; seen || !b.isWalkable(n) {
+				continue
+			}
+			owner[n] = owner[curr]
+			queue = append(queue, n)
+		}
+	}
+	return owner
+}
+
+// farthestCell - достижимая клетка, максимально удаленная от уже стоящих якорей
+// (без якорей - ближайшая к центру достижимой области)
+func (b *Bot) farthestCell(reach map[domain.Vec2d]int, anchors []domain.Vec2d) domain.Vec2d {
+	var best domain.Vec2d
+	bestScore := math.MinInt32
+	cx, cy, n := 0, 0, 0
+	if len(anchors) == 0 {
+		for c := range reach {
+			cx, cy, n = cx+c.X(), cy+c.Y(), n+1
+		}
+		cx, cy = cx/n, cy/n
+	}
+	for c := range reach {
+		score := 0
+		if len(anchors) == 0 {
+			score = -(abs(c.X()-cx) + abs(c.Y()-cy))
+		} else {
+			score = math.MaxInt32
+			for _, a := range anchors {
+				if d := b.manhattan(a, c); d < score {
+					score = d
+				}
+			}
+		}
+		// Обход map случайный - при равенстве берем меньшую клетку, чтобы результат был стабильным
+		if score > bestScore || (score == bestScore && (c.X() < best.X() || c.X() == best.X() && c.Y() < best.Y())) {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+// regionOf - чья область клетка ("" - ничья или недостижима)
+func (b *Bot) regionOf(p domain.Vec2d) string {
+	if b.Regions == nil || !b.isValid(p) || p.X() >= len(b.Regions) {
+		return ""
+	}
+	return b.Regions[p.X()][p.Y()]
+}
+
+// staysInRegion - охотники и охранники ходят за целью куда угодно, остальные держатся своей области
+func (b *Bot) staysInRegion(id string) bool {
+	role := b.roleOf(id)
+	return role != RoleHunter && role != RoleGuard
+}
@@ -0,0 +1,355 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+	"sort"
+)
+
+const (
+	trapSearchDepth  = 8   // насколько далеко от врага ищем клетки-заглушки
+	trapMaxPocket    = 16  // больше - это уже не тупик, а открытая местность
+	trapMaxSeals     = 2   // сколько бомб (юнитов) максимум закрывают ловушку
+	trapMinEnemySpd  = 0.5 // стоящий враг тоже может побежать
+	trapUnitReachMax = 30  // глубина BFS для наших юнитов
+)
+
+// TrapPlan - ловушка на врага: бомбы-заглушки закрывают выходы, взрыв накрывает весь карман
+type TrapPlan struct {
+	EnemyID string
+	Pocket  []domain.Vec2d
+	Bombs   map[string]domain.Vec2d // unitID -> клетка бомбы
+	cover   map[domain.Vec2d]bool   // общий взрыв заглушек - туда не убегаем
+}
+
+type trapUnit struct {
+	unit domain.Unit
+	dist map[domain.Vec2d]int
+}
+
+// planTraps ищет врагов в коридорах и тупиках и раздает юнитам задания на закрывающие бомбы
+func (b *Bot) planTraps(units []domain.Unit) []TrapPlan {
+	b.clearOrders(OrderTrap)
+
+	free := []*trapUnit{}
+	for _, u := range units {
+		if u.BombCount > 0 && b.Orders[u.ID] == nil {
+			free = append(free, &trapUnit{unit: u, dist: b.bfsDistances(u.Pos, trapUnitReachMax)})
+		}
+	}
+
+	plans := []TrapPlan{}
+	for _, e := range b.State.Enemies {
+		if len(free) == 0 {
+			break
+		}
+		plan, ok := b.planTrap(e, free)
+		if !ok {
+			continue
+		}
+		plans = append(plans, plan)
+		for unitID, pos := range plan.Bombs {
+			arrival := b.travelSeconds(b.unitDist(free, unitID, pos))
+			b.setOrder(unitID, &UnitOrder{Kind: OrderTrap, Target: pos, Ref: e.ID, Until: b.Tick + b.secondsToTicks(arrival) + 2, Avoid: plan.cover})
+			free = removeTrapUnit(free, unitID)
+		}
+	}
+	return plans
+}
+
+func (b *Bot) planTrap(e domain.EnemyUnit, free []*trapUnit) (TrapPlan, bool) {
+	speed := trapMinEnemySpd
+	if t, ok := b.Enemies.Tracks[e.ID]; ok && t.Speed > speed {
+		speed = t.Speed
+	}
+	// Бомбы на карте - стенки для врага, даже когда на сетке они уже TileDanger (таймер меньше трех секунд)
+	onMap := make(map[domain.Vec2d]bool, len(b.State.Arena.Bombs))
+	for _, bomb := range b.State.Arena.Bombs {
+		onMap[bomb.Pos] = true
+	}
+	enemyDist := b.enemyDistances(e.Pos, onMap, trapSearchDepth, math.MaxInt)
+
+	candidates := make([]domain.Vec2d, 0, len(enemyDist))
+	for pos := range enemyDist {
+		if pos != e.Pos && b.isWalkable(pos) {
+			candidates = append(candidates, pos)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		di, dj := enemyDist[candidates[i]], enemyDist[candidates[j]]
+		if di != dj {
+			return di < dj
+		}
+		return candidates[i][0]*1000+candidates[i][1] < candidates[j][0]*1000+candidates[j][1]
+	})
+
+	// Сначала одна заглушка (классический тупик), потом пары (коридор с двумя выходами)
+	for _, s := range candidates {
+		if plan, ok := b.tryTrap(e, speed, enemyDist, onMap, []domain.Vec2d{s}, free); ok {
+			return plan, true
+		}
+	}
+	if trapMaxSeals < 2 || len(free) < 2 {
+		return TrapPlan{}, false
+	}
+	for i := 0; i < len(candidates); i++ {
+		for j := i + 1; j < len(candidates); j++ {
+			if plan, ok := b.tryTrap(e, speed, enemyDist, onMap, []domain.Vec2d{candidates[i], candidates[j]}, free); ok {
+				return plan, true
+			}
+		}
+	}
+	return TrapPlan{}, false
+}
+
+// tryTrap проверяет набор заглушек: карман закрыт, юниты успевают раньше врага, весь карман взрывается разом
+func (b *Bot) tryTrap(e domain.EnemyUnit, enemySpeed float64, enemyDist map[domain.Vec2d]int, onMap map[domain.Vec2d]bool, seals []domain.Vec2d, free []*trapUnit) (TrapPlan, bool) {
+	blocked := make(map[domain.Vec2d]bool, len(onMap)+len(seals))
+	for p := range onMap {
+		blocked[p] = true
+	}
+	for _, s := range seals {
+		blocked[s] = true
+	}
+	pocket := b.enemyDistances(e.Pos, blocked, trapMaxPocket, trapMaxPocket)
+	if len(pocket) == 0 || len(pocket) >= trapMaxPocket {
+		return TrapPlan{}, false
+	}
+
+	// Каждую заглушку ставит свой юнит, и успевает до того, как враг до нее добежит
+	bombs := make(map[string]domain.Vec2d, len(seals))
+	latest := 0.0
+	for _, s := range seals {
+		bestID, bestT := "", math.MaxFloat64
+		for _, tu := range free {
+			if _, used := bombs[tu.unit.ID]; used {
+				continue
+			}
+			if isInPocket(pocket, tu.unit.Pos) {
+				continue // юнит сам внутри кармана
+			}
+			d, ok := tu.dist[s]
+			if !ok {
+				continue
+			}
+			if t := b.travelSeconds(d); t < bestT {
+				bestID, bestT = tu.unit.ID, t
+			}
+		}
+		if bestID == "" {
+			return TrapPlan{}, false
+		}
+		if float64(enemyDist[s])/enemySpeed <= bestT {
+			return TrapPlan{}, false
+		}
+		bombs[bestID] = s
+		latest = math.Max(latest, bestT)
+	}
+
+	// Ловушку перепланируем каждый тик, и бомбы, что уже стоят у края кармана (первая заглушка),
+	// держат его наравне с новыми. Годится один из двух взрывов: новые заглушки сами накрывают карман,
+	// пока стоящие бомбы еще держат выходы, или все бомбы рвутся одной цепочкой - тогда новые встают до первого взрыва.
+	placed := b.placedSeals(pocket)
+	fireMs := int(latest*1000) + b.BombDelay
+	cover, together := b.plannedBlast(seals)
+	if !together || !coversPocket(cover, pocket) || !standsUntil(placed, b.Detonation, fireMs) {
+		all := append([]domain.Vec2d(nil), seals...)
+		for _, bomb := range placed {
+			all = append(all, bomb.Pos)
+		}
+		if cover, together = b.plannedBlast(all); !together || !coversPocket(cover, pocket) {
+			return TrapPlan{}, false
+		}
+		for _, bomb := range placed {
+			timer := bombTimer(bomb, b.Detonation)
+			if latest >= timer {
+				return TrapPlan{}, false // стоящая бомба рванет раньше, чем встанут новые заглушки
+			}
+			fireMs = min(fireMs, int(timer*1000))
+		}
+	}
+	if !killableAt(e.SafeTime, fireMs) {
+		return TrapPlan{}, false
+	}
+
+	cells := make([]domain.Vec2d, 0, len(pocket))
+	for pos := range pocket {
+		cells = append(cells, pos)
+	}
+	return TrapPlan{EnemyID: e.ID, Pocket: cells, Bombs: bombs, cover: cover}, true
+}
+
+// coversPocket - взрыв накрывает весь карман
+func coversPocket(cover map[domain.Vec2d]bool, pocket map[domain.Vec2d]int) bool {
+	for pos := range pocket {
+		if !cover[pos] {
+			return false
+		}
+	}
+	return true
+}
+
+// bombTimer - секунд до взрыва бомбы на карте с учетом цепочек
+func bombTimer(bomb domain.Bomb, detonation map[domain.Vec2d]float64) float64 {
+	if t, ok := detonation[bomb.Pos]; ok {
+		return t
+	}
+	return bomb.Timer
+}
+
+// standsUntil - все бомбы еще на карте через fireMs миллисекунд
+func standsUntil(bombs []domain.Bomb, detonation map[domain.Vec2d]float64, fireMs int) bool {
+	for _, bomb := range bombs {
+		if int(bombTimer(bomb, detonation)*1000) <= fireMs {
+			return false
+		}
+	}
+	return true
+}
+
+// placedSeals - бомбы на карте у края кармана: враг через них не пройдет
+func (b *Bot) placedSeals(pocket map[domain.Vec2d]int) []domain.Bomb {
+	var out []domain.Bomb
+	for _, bomb := range b.State.Arena.Bombs {
+		if isInPocket(pocket, bomb.Pos) {
+			continue
+		}
+		for _, n := range b.neighbors(bomb.Pos) {
+			if isInPocket(pocket, n) {
+				out = append(out, bomb)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// plannedBlast - клетки, накрытые взрывом запланированных бомб. together=false,
+// если бомбы не подрывают друг друга цепочкой и взорвутся в разное время.
+func (b *Bot) plannedBlast(bombs []domain.Vec2d) (map[domain.Vec2d]bool, bool) {
+	planned := make(map[domain.Vec2d]bool, len(bombs))
+	for _, p := range bombs {
+		planned[p] = true
+	}
+	cover := make(map[domain.Vec2d]bool)
+	hits := make(map[domain.Vec2d][]domain.Vec2d)
+	for _, p := range bombs {
+		for _, c := range b.blastCells(p, planned) {
+			cover[c] = true
+			if c != p && planned[c] {
+				hits[p] = append(hits[p], c)
+			}
+		}
+	}
+
+	// Цепочка: от первой бомбы взрывом должны достаться все остальные
+	reached := map[domain.Vec2d]bool{bombs[0]: true}
+	queue := []domain.Vec2d{bombs[0]}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		for _, n := range hits[curr] {
+			if !reached[n] {
+				reached[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return cover, len(reached) == len(bombs)
+}
+
+// blastCells - клетки, которые накроет бомба в p: луч идет, пока клетка в линии взрыва по isInBombLine
+// (та же геометрия, что у markDanger). Бомбы planned еще не на карте, но остановят луч, как настоящие.
+func (b *Bot) blastCells(p domain.Vec2d, planned map[domain.Vec2d]bool) []domain.Vec2d {
+	cells := []domain.Vec2d{p}
+	dirs := []domain.Vec2d{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+	for _, d := range dirs {
+		for i := 1; i <= b.BombRange; i++ {
+			c := domain.Vec2d{p.X() + d.X()*i, p.Y() + d.Y()*i}
+			if !b.isValid(c) || b.Grid[c.X()][c.Y()] == TileWall || !b.isInBombLine(c, p) {
+				break
+			}
+			cells = append(cells, c)
+			if planned[c] {
+				break
+			}
+		}
+	}
+	return cells
+}
+
+// enemyDistances - BFS по клеткам, проходимым для вражеского юнита (он, как и мы, ходит сквозь юнитов)
+// Останавливается, набрав maxCells клеток.
+func (b *Bot) enemyDistances(from domain.Vec2d, blocked map[domain.Vec2d]bool, maxDepth, maxCells int) map[domain.Vec2d]int {
+	dist := map[domain.Vec2d]int{from: 0}
+	queue := []domain.Vec2d{from}
+	for len(queue) > 0 && len(dist) < maxCells {
+		curr := queue[0]
+		queue = queue[1:]
+		if dist[curr] >= maxDepth {
+			continue
+		}
+		for _, n := range b.neighbors(curr) {
+			if _, seen := dist[n]; seen || blocked[n] || !b.isValid(n) {
+				continue
+			}
+			if t := b.Grid[n.X()][n.Y()]; t == TileWall || t == TileBox || t == TileBomb {
+				continue
+			}
+			dist[n] = dist[curr] + 1
+			queue = append(queue, n)
+		}
+	}
+	return dist
+}
+
+// bfsDistances - расстояния в шагах от from до всех клеток, куда может дойти наш юнит
+func (b *Bot) bfsDistances(from domain.Vec2d, maxDepth int) map[domain.Vec2d]int {
+	dist := map[domain.Vec2d]int{from: 0}
+	queue := []domain.Vec2d{from}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		if dist[curr] >= maxDepth {
+			continue
+		}
+		for _, n := range b.neighbors(curr) {
+			if _, seen := dist[n]; seen || !b.isWalkable(n) {
+				continue
+			}
+			dist[n] = dist[curr] + 1
+			queue = append(queue, n)
+		}
+	}
+	return dist
+}
+
+func (b *Bot) travelSeconds(steps int) float64 { return float64(steps) / float64(b.Speed) }
+
+func (b *Bot) secondsToTicks(sec float64) int {
+	return int(math.Ceil(sec * 1000 / float64(b.TickInterval.Milliseconds())))
+}
+
+func (b *Bot) unitDist(units []*trapUnit, unitID string, pos domain.Vec2d) int {
+	for _, tu := range units {
+		if tu.unit.ID == unitID {
+			return tu.dist[pos]
+		}
+	}
+	return 0
+}
+
+func isInPocket(pocket map[domain.Vec2d]int, pos domain.Vec2d) bool {
+	_, ok := pocket[pos]
+	return ok
+}
+
+func removeTrapUnit(units []*trapUnit, unitID string) []*trapUnit {
+	out := units[:0]
+	for _, tu := range units {
+		if tu.unit.ID != unitID {
+			out = append(out, tu)
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"gorutin/internal/domain"
+	"math"
+	"sort"
+)
+
+// UnitInfo - жизненный цикл нашего юнита: когда появился, какая роль и область
+type UnitInfo struct {
+	ID       string        `json:"id"`
+	BornTick int           `json:"born_tick"`
+	Role     string        `json:"role"`
+	Region   int           `json:"region"`  // клеток в своей области карты
+	Heading  domain.Vec2d  `json:"heading"` // направление к якорю своей области для блуждания
+	Anchor   *domain.Vec2d `json:"anchor"`  // центр области, см. partitionTerritory
+}
+
+// syncUnits находит новых юнитов (старт раунда, возрождение, "софт скилы"),
+// чистит состояние погибших и пересматривает роли, если состав изменился
+func (b *Bot) syncUnits() {
+	changed := false
+	present := make(map[string]bool, len(b.State.MyUnits))
+
+	for _, u := range b.State.MyUnits {
+		present[u.ID] = true
+		_, known := b.Units[u.ID]
+		switch {
+		case u.Alive && !known:
+			b.Units[u.ID] = &UnitInfo{ID: u.ID, BornTick: b.Tick}
+			changed = true
+		case !u.Alive && known:
+			b.forgetUnit(u.ID)
+			changed = true
+		case !u.Alive:
+			b.forgetUnit(u.ID) // мертвый с прошлого раунда - на всякий случай
+		}
+	}
+	for id := range b.Units {
+		if !present[id] {
+			b.forgetUnit(id)
+			changed = true
+		}
+	}
+
+	if changed {
+		b.rebalanceUnits()
+	}
+}
+
+// forgetUnit убирает все, что бот помнит про юнита
+func (b *Bot) forgetUnit(id string) {
+	b.releaseTarget(id)
+	delete(b.Orders, id)
+	delete(b.UnitExploreDirs, id)
+	delete(b.Units, id)
+	if b.Chain != nil {
+		delete(b.Chain.Units, id)
+	}
+}
+
+// rebalanceUnits сбрасывает направления блуждания и роли при смене состава.
+// Области карты пересчитываются каждый тик в partitionTerritory.
+func (b *Bot) rebalanceUnits() {
+	for id := range b.Units {
+		delete(b.UnitExploreDirs, id)
+	}
+	b.rolesTick = 0
+}
+
+// preferHeading выбирает из допустимых направлений то, что ближе к области юнита
+func (b *Bot) preferHeading(id string, dirs []domain.Vec2d) (domain.Vec2d, bool) {
+	info, ok := b.Units[id]
+	if !ok || info.Heading == (domain.Vec2d{}) {
+		return domain.Vec2d{}, false
+	}
+	best, bestDot := domain.Vec2d{}, math.MinInt
+	for _, d := range dirs {
+		if dot := d.X()*info.Heading.X() + d.Y()*info.Heading.Y(); dot > bestDot {
+			best, bestDot = d, dot
+		}
+	}
+	return best, bestDot > 0
+}
+
+// UnitsOverlay - роли и области юнитов для viz
+func (b *Bot) UnitsOverlay() []UnitInfo {
+	out := make([]UnitInfo, 0, len(b.Units))
+	for _, info := range b.Units {
+		out = append(out, *info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
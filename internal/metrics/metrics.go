@@ -0,0 +1,246 @@
+// Package metrics - счетчики, датчики и гистограммы в текстовом формате Prometheus (exposition 0.0.4)
+// без внешних зависимостей: хватает, чтобы локальный Prometheus/Grafana смотрел за долгими сессиями.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets - границы гистограмм задержек в секундах: от миллисекунды до пары секунд (таймаут клиента)
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Registry - набор метрик, которые отдает /metrics
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*metric
+}
+
+func NewRegistry() *Registry { return &Registry{} }
+
+// metric - семейство рядов с общими именем и набором меток
+type metric struct {
+	name, help, kind string
+	labels           []string
+	buckets          []float64      // только у гистограмм
+	fn               func() float64 // метрика, которая считывается при выдаче (без меток)
+	series           map[string]*series
+}
+
+type series struct {
+	values []string // значения меток
+	value  float64  // счетчик или датчик; у гистограммы - сумма
+	counts []uint64 // гистограмма: попадания в каждый bucket (не накопленные)
+	count  uint64
+}
+
+func (r *Registry) register(m *metric) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.metrics {
+		if o.name == m.name {
+			panic("metrics: duplicate metric " + m.name)
+		}
+	}
+	m.series = map[string]*series{}
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// at - ряд с заданными значениями меток; вызывать под r.mu
+func (m *metric) at(values []string) *series {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s wants labels %v, got %v", m.name, m.labels, values))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if m.buckets != nil {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// Counter - только растущий счетчик
+type Counter struct {
+	r *Registry
+	m *metric
+}
+
+// Counter заводит счетчик; имя по соглашению Prometheus кончается на _total
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r, r.register(&metric{name: name, help: help, kind: "counter", labels: labels})}
+}
+
+// Inc прибавляет единицу к ряду с метками values
+func (c *Counter) Inc(values ...string) { c.Add(1, values...) }
+
+// Add прибавляет v (отрицательное игнорируется: счетчик не убывает)
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.m.at(values).value += v
+}
+
+// Gauge - значение, которое может и расти, и убывать
+type Gauge struct {
+	r *Registry
+	m *metric
+}
+
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r, r.register(&metric{name: name, help: help, kind: "gauge", labels: labels})}
+}
+
+func (g *Gauge) Set(v float64, values ...string) {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.m.at(values).value = v
+}
+
+// Histogram - распределение замеров по bucket'ам
+type Histogram struct {
+	r *Registry
+	m *metric
+}
+
+// Histogram заводит гистограмму; buckets - верхние границы по возрастанию (+Inf добавляется сам)
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &Histogram{r, r.register(&metric{name: name, help: help, kind: "histogram", labels: labels, buckets: b})}
+}
+
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.r.mu.Lock()
+	defer h.r.mu.Unlock()
+	s := h.m.at(values)
+	if i := sort.SearchFloat64s(h.m.buckets, v); i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.value += v
+	s.count++
+}
+
+// CounterFunc - счетчик, который ведет кто-то другой; f читается при каждой выдаче
+func (r *Registry) CounterFunc(name, help string, f func() float64) {
+	r.register(&metric{name: name, help: help, kind: "counter", fn: f})
+}
+
+// GaugeFunc - датчик, значение которого читается при каждой выдаче
+func (r *Registry) GaugeFunc(name, help string, f func() float64) {
+	r.register(&metric{name: name, help: help, kind: "gauge", fn: f})
+}
+
+// WriteText пишет все метрики в текстовом формате Prometheus
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]*metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		// Функции зовем без блокировки: они могут сами брать чужие мьютексы
+		var fnValue float64
+		if m.fn != nil {
+			fnValue = m.fn()
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", m.name, escapeHelp(m.help), m.name, m.kind)
+		if m.fn != nil {
+			fmt.Fprintf(bw, "%s %s\n", m.name, formatFloat(fnValue))
+			continue
+		}
+		r.mu.Lock()
+		m.write(bw)
+		r.mu.Unlock()
+	}
+	return bw.Flush()
+}
+
+// write - ряды метрики по порядку значений меток; вызывать под r.mu
+func (m *metric) write(w io.Writer) {
+	keys := make([]string, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := m.series[k]
+		if m.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", m.name, labelSet(m.labels, s.values, "", ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", m.name, labelSet(m.labels, s.values, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", m.name, labelSet(m.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", m.name, labelSet(m.labels, s.values, "", ""), formatFloat(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", m.name, labelSet(m.labels, s.values, "", ""), s.count)
+	}
+}
+
+// Handler - обработчик /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// labelSet - {a="1",b="2"} с дополнительной меткой extra (le у гистограмм), "" - если меток нет
+func labelSet(names, values []string, extra, extraValue string) string {
+	if len(names) == 0 && extra == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", n, escapeLabel(values[i]))
+	}
+	if extra != "" {
+		if len(names) > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", extra, extraValue)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
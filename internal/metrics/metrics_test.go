@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	reqs := r.Counter("bot_requests_total", "Запросы к API.\nС переводом строки и \\.", "endpoint", "code")
+	reqs.Inc("/rounds", "200")
+	reqs.Add(2, "/move", "200")
+	reqs.Add(-5, "/move", "200") // счетчик не убывает
+	reqs.Inc(`a"b\c`+"\nd", "500")
+
+	r.Gauge("bot_units", "Живые юниты.").Set(3)
+
+	lat := r.Histogram("bot_latency_seconds", "Задержка.", []float64{0.5, 0.1, 1}, "endpoint")
+	for _, v := range []float64{0.05, 0.1, 0.3, 0.7, 2} {
+		lat.Observe(v, "/move")
+	}
+
+	r.CounterFunc("bot_ticks_total", "Тики.", func() float64 { return 42 })
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP bot_requests_total Запросы к API.\nС переводом строки и \\.
+# TYPE bot_requests_total counter
+bot_requests_total{endpoint="/move",code="200"} 2
+bot_requests_total{endpoint="/rounds",code="200"} 1
+bot_requests_total{endpoint="a\"b\\c\nd",code="500"} 1
+# HELP bot_units Живые юниты.
+# TYPE bot_units gauge
+bot_units 3
+# HELP bot_latency_seconds Задержка.
+# TYPE bot_latency_seconds histogram
+bot_latency_seconds_bucket{endpoint="/move",le="0.1"} 2
+bot_latency_seconds_bucket{endpoint="/move",le="0.5"} 3
+bot_latency_seconds_bucket{endpoint="/move",le="1"} 4
+bot_latency_seconds_bucket{endpoint="/move",le="+Inf"} 5
+bot_latency_seconds_sum{endpoint="/move"} 3.15
+bot_latency_seconds_count{endpoint="/move"} 5
+# HELP bot_ticks_total Тики.
+# TYPE bot_ticks_total counter
+bot_ticks_total 42
+`
+	if got := sb.String(); got != want {
+		t.Errorf("WriteText:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHandlerContentType(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewRegistry().Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestLabelsMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic on wrong label count")
+		}
+	}()
+	NewRegistry().Counter("x_total", "x", "a").Inc()
+}
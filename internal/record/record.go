@@ -0,0 +1,161 @@
+// Package record пишет и читает записи раундов: снимки арены и отправленные по ним команды.
+// Формат - JSON по кадру на строку, сжатый gzip (*.jsonl.gz).
+package record
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorutin/internal/domain"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const Ext = ".jsonl.gz"
+
+// Frame - снимок арены и команда, отправленная по нему (nil - ничего не отправляли).
+// Lead и Boosters - с чем бот планировал ход: replay повторяет ту же проекцию и те же усиления.
+type Frame struct {
+	At       time.Time             `json:"at"`
+	State    *domain.GameState     `json:"state"`
+	Command  *domain.PlayerCommand `json:"command,omitempty"`
+	Lead     time.Duration         `json:"lead,omitempty"`     // на сколько снимок сдвинут вперед (ProjectState)
+	Boosters *domain.BoosterState  `json:"boosters,omitempty"` // nil - состояние усилений еще не знали
+}
+
+// Recorder пишет кадры текущего раунда в свой файл. Пустой dir - запись выключена.
+type Recorder struct {
+	dir string
+
+	mu     sync.Mutex
+	file   *os.File
+	gz     *gzip.Writer
+	enc    *json.Encoder
+	path   string
+	frames int
+}
+
+func NewRecorder(dir string) *Recorder { return &Recorder{dir: dir} }
+
+// Start закрывает запись прошлого раунда и начинает файл для round
+func (r *Recorder) Start(round string) error {
+	if r.dir == "" {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.closeLocked(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s%s", sanitize(round), time.Now().Format("20060102-150405"), Ext)
+	path := filepath.Join(r.dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	r.file, r.gz, r.path, r.frames = f, gzip.NewWriter(f), path, 0
+	r.enc = json.NewEncoder(r.gz)
+	return nil
+}
+
+// Record дописывает кадр; без начатой записи ничего не делает
+func (r *Recorder) Record(f Frame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.enc == nil {
+		return nil
+	}
+	r.frames++
+	return r.enc.Encode(f)
+}
+
+// Close дописывает и закрывает текущий файл. Возвращает путь и число кадров ("" - записи не было).
+func (r *Recorder) Close() (string, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	path, frames := r.path, r.frames
+	return path, frames, r.closeLocked()
+}
+
+func (r *Recorder) closeLocked() error {
+	if r.file == nil {
+		return nil
+	}
+	err := errors.Join(r.gz.Close(), r.file.Close())
+	r.file, r.gz, r.enc, r.path, r.frames = nil, nil, nil, "", 0
+	return err
+}
+
+// Reader читает кадры записи по одному
+type Reader struct {
+	file *os.File
+	gz   *gzip.Reader
+	dec  *json.Decoder
+}
+
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Reader{file: f, gz: gz, dec: json.NewDecoder(gz)}, nil
+}
+
+// Next - следующий кадр; io.EOF - запись кончилась
+func (r *Reader) Next() (Frame, error) {
+	var f Frame
+	err := r.dec.Decode(&f)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF // запись оборвалась (бот убит посреди раунда) - читаем, что успело записаться
+	}
+	return f, err
+}
+
+func (r *Reader) Close() error { return errors.Join(r.gz.Close(), r.file.Close()) }
+
+// Files - записи по пути: сам файл или все записи в каталоге по имени
+func Files(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(path, "*"+Ext))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no %s recordings", path, Ext)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func sanitize(name string) string {
+	if name == "" {
+		return "round"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,487 @@
+// Package sim - упрощенный локальный движок игры по правилам doc.md: карта со стенами и
+// препятствиями, наши юниты, бомбы с цепочками, патрульные мобы и бродячие враги.
+// Нужен, чтобы гонять бота без сервера: команда sim и фикстуры для bench.
+package sim
+
+import (
+	"fmt"
+	"gorutin/internal/domain"
+	"math/rand"
+	"time"
+)
+
+// TickPeriod - дискретизация мира (doc.md: около 50мс)
+const TickPeriod = 50 * time.Millisecond
+
+const (
+	bombDelay     = 8 * time.Second
+	mobSleep      = 10 * time.Second
+	respawnSafe   = 5 * time.Second
+	mobSpeed      = 1 // клеток в секунду
+	enemySpeed    = 2
+	killPoints    = 10
+	maxBoxPoints  = 4  // за препятствия одним взрывом: 1+2+3+4
+	respawnFine   = 10 // процентов очков
+	spawnClearing = 2  // радиус расчистки вокруг точки появления
+)
+
+// Options - параметры карты и населения
+type Options struct {
+	Width, Height int
+	Units         int
+	Enemies       int
+	Mobs          int
+	ObstacleRate  float64 // доля свободных клеток под препятствиями
+	View          int
+	Seed          int64
+}
+
+// DefaultOptions - карта примерно как на тестовых раундах
+func DefaultOptions() Options {
+	return Options{Width: 60, Height: 60, Units: 6, Enemies: 4, Mobs: 3, ObstacleRate: 0.3, View: 5, Seed: 1}
+}
+
+// Validate - годятся ли параметры для New: на слишком маленькой или сплошь заставленной карте негде появиться
+func (o Options) Validate() error {
+	switch {
+	case o.Width < 3 || o.Height < 3:
+		return fmt.Errorf("map must be at least 3x3, got %dx%d", o.Width, o.Height)
+	case o.ObstacleRate < 0 || o.ObstacleRate >= 1:
+		return fmt.Errorf("obstacle rate must be in [0, 1), got %v", o.ObstacleRate)
+	case o.Units < 1:
+		return fmt.Errorf("need at least 1 unit, got %d", o.Units)
+	case o.Enemies < 0 || o.Mobs < 0:
+		return fmt.Errorf("enemies and mobs can't be negative, got %d and %d", o.Enemies, o.Mobs)
+	case o.View < 1:
+		return fmt.Errorf("view must be at least 1, got %d", o.View)
+	}
+	return nil
+}
+
+// Stats - итоги симуляции
+type Stats struct {
+	Score     int
+	Obstacles int // разрушено препятствий
+	Kills     int // врагов и мобов
+	Deaths    int
+	Respawns  int
+	Rejected  int // команд, отброшенных как невалидные
+	Bombs     int
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("score %d | obstacles %d | kills %d | deaths %d | respawns %d | bombs %d | rejected %d",
+		s.Score, s.Obstacles, s.Kills, s.Deaths, s.Respawns, s.Bombs, s.Rejected)
+}
+
+type unit struct {
+	id        string
+	pos       domain.Vec2d
+	alive     bool
+	path      []domain.Vec2d
+	bombs     map[domain.Vec2d]bool
+	progress  float64 // накопленная доля шага
+	available int
+	safeUntil time.Duration
+}
+
+type bomb struct {
+	pos       domain.Vec2d
+	owner     *unit
+	explodeAt time.Duration
+	radius    int
+}
+
+// walker - моб или вражеский юнит: бредет в случайном свободном направлении
+type walker struct {
+	id       string
+	pos      domain.Vec2d
+	dir      domain.Vec2d
+	alive    bool
+	wakeAt   time.Duration
+	progress float64
+	speed    int
+}
+
+// Engine - состояние мира; не потокобезопасен
+type Engine struct {
+	opts Options
+	rng  *rand.Rand
+	now  time.Duration
+
+	walls     map[domain.Vec2d]bool
+	obstacles map[domain.Vec2d]bool
+	units     []*unit
+	bombs     []*bomb
+	mobs      []*walker
+	enemies   []*walker
+
+	speed, radius, maxBombs int
+	stats                   Stats
+}
+
+var dirs = []domain.Vec2d{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+
+func New(opts Options) *Engine {
+	e := &Engine{
+		opts:      opts,
+		rng:       rand.New(rand.NewSource(opts.Seed)),
+		walls:     make(map[domain.Vec2d]bool),
+		obstacles: make(map[domain.Vec2d]bool),
+		speed:     2,
+		radius:    1,
+		maxBombs:  1,
+	}
+	for x := 0; x < opts.Width; x++ {
+		for y := 0; y < opts.Height; y++ {
+			p := domain.Vec2d{x, y}
+			if x%2 == 1 && y%2 == 1 {
+				e.walls[p] = true
+			} else if e.rng.Float64() < opts.ObstacleRate {
+				e.obstacles[p] = true
+			}
+		}
+	}
+
+	spawn := e.freeCell()
+	e.clearAround(spawn)
+	for i := 0; i < opts.Units; i++ {
+		e.units = append(e.units, &unit{id: fmt.Sprintf("u%d", i), pos: spawn, alive: true, available: e.maxBombs, safeUntil: respawnSafe})
+	}
+	for i := 0; i < opts.Enemies; i++ {
+		e.enemies = append(e.enemies, &walker{id: fmt.Sprintf("e%d", i), pos: e.freeCell(), alive: true, speed: enemySpeed})
+	}
+	for i := 0; i < opts.Mobs; i++ {
+		e.mobs = append(e.mobs, &walker{id: fmt.Sprintf("m%d", i), pos: e.freeCell(), alive: true, wakeAt: mobSleep, speed: mobSpeed})
+	}
+	return e
+}
+
+func (e *Engine) Elapsed() time.Duration { return e.now }
+func (e *Engine) Stats() Stats           { return e.stats }
+
+func (e *Engine) valid(p domain.Vec2d) bool {
+	return p.X() >= 0 && p.Y() >= 0 && p.X() < e.opts.Width && p.Y() < e.opts.Height
+}
+
+func (e *Engine) bombAt(p domain.Vec2d) *bomb {
+	for _, b := range e.bombs {
+		if b.pos == p {
+			return b
+		}
+	}
+	return nil
+}
+
+func (e *Engine) passable(p domain.Vec2d) bool {
+	return e.valid(p) && !e.walls[p] && !e.obstacles[p] && e.bombAt(p) == nil
+}
+
+// freeCell - случайная проходимая клетка. Если случайно не нашли - первая проходимая по порядку,
+// а если таких нет совсем - угол (0,0): стены там не бывает, препятствие убираем.
+func (e *Engine) freeCell() domain.Vec2d {
+	for range e.opts.Width * e.opts.Height {
+		p := domain.Vec2d{e.rng.Intn(e.opts.Width), e.rng.Intn(e.opts.Height)}
+		if e.passable(p) {
+			return p
+		}
+	}
+	for x := 0; x < e.opts.Width; x++ {
+		for y := 0; y < e.opts.Height; y++ {
+			if p := (domain.Vec2d{x, y}); e.passable(p) {
+				return p
+			}
+		}
+	}
+	corner := domain.Vec2d{0, 0}
+	delete(e.obstacles, corner)
+	return corner
+}
+
+func (e *Engine) clearAround(p domain.Vec2d) {
+	for dx := -spawnClearing; dx <= spawnClearing; dx++ {
+		for dy := -spawnClearing; dy <= spawnClearing; dy++ {
+			delete(e.obstacles, domain.Vec2d{p.X() + dx, p.Y() + dy})
+		}
+	}
+}
+
+func (e *Engine) seen(p domain.Vec2d) bool {
+	for _, u := range e.units {
+		dx, dy := p.X()-u.pos.X(), p.Y()-u.pos.Y()
+		if u.alive && dx*dx+dy*dy <= e.opts.View*e.opts.View {
+			return true
+		}
+	}
+	return false
+}
+
+// State - то, что вернул бы /api/arena: все про наших юнитов, остальное - только в обзоре
+func (e *Engine) State() *domain.GameState {
+	s := &domain.GameState{MapSize: domain.Vec2d{e.opts.Width, e.opts.Height}, Round: "sim", RawScore: e.stats.Score}
+	// Обходим сеткой, а не по map: одинаковый seed должен давать одинаковую игру
+	for x := 0; x < e.opts.Width; x++ {
+		for y := 0; y < e.opts.Height; y++ {
+			p := domain.Vec2d{x, y}
+			switch {
+			case !e.walls[p] && !e.obstacles[p]:
+			case !e.seen(p):
+			case e.walls[p]:
+				s.Arena.Walls = append(s.Arena.Walls, p)
+			default:
+				s.Arena.Obstacles = append(s.Arena.Obstacles, p)
+			}
+		}
+	}
+	for _, b := range e.bombs {
+		if e.seen(b.pos) {
+			s.Arena.Bombs = append(s.Arena.Bombs, domain.Bomb{Pos: b.pos, Timer: (b.explodeAt - e.now).Seconds(), Radius: b.radius})
+		}
+	}
+	for _, u := range e.units {
+		canMove := len(u.path) == 0
+		s.MyUnits = append(s.MyUnits, domain.Unit{
+			ID: u.id, Pos: u.pos, Alive: u.alive, BombCount: u.available, CanMove: &canMove,
+			SafeTime: int(max(0, u.safeUntil-e.now).Milliseconds()),
+		})
+	}
+	for _, en := range e.enemies {
+		if en.alive && e.seen(en.pos) {
+			s.Enemies = append(s.Enemies, domain.EnemyUnit{ID: en.id, Pos: en.pos})
+		}
+	}
+	for _, m := range e.mobs {
+		if m.alive && e.seen(m.pos) {
+			s.Mobs = append(s.Mobs, domain.Mob{ID: m.id, Pos: m.pos, Type: "patrol", SafeTime: int(max(0, m.wakeAt-e.now).Milliseconds())})
+		}
+	}
+	return s
+}
+
+// Apply принимает команды; как и сервер, невалидную команду юнита отбрасывает целиком.
+// Возвращает принятые команды и ошибки по отброшенным.
+func (e *Engine) Apply(cmd domain.PlayerCommand) (domain.PlayerCommand, []error) {
+	var accepted domain.PlayerCommand
+	var errs []error
+	for _, c := range cmd.Bombers {
+		if err := e.applyUnit(c); err != nil {
+			e.stats.Rejected++
+			errs = append(errs, fmt.Errorf("%s: %w", c.ID, err))
+			continue
+		}
+		accepted.Bombers = append(accepted.Bombers, c)
+	}
+	return accepted, errs
+}
+
+func (e *Engine) applyUnit(c domain.UnitCommand) error {
+	var u *unit
+	for _, cand := range e.units {
+		if cand.id == c.ID {
+			u = cand
+		}
+	}
+	switch {
+	case u == nil || !u.alive:
+		return fmt.Errorf("no such alive unit")
+	case len(u.path) > 0:
+		return fmt.Errorf("unit is still moving")
+	case len(c.Path) > domain.MaxPathLen:
+		return fmt.Errorf("path longer than %d", domain.MaxPathLen)
+	}
+	prev := u.pos
+	onPath := map[domain.Vec2d]bool{u.pos: true}
+	for _, p := range c.Path {
+		if !p.Adjacent(prev) {
+			return fmt.Errorf("step %v -> %v is not adjacent", prev, p)
+		}
+		onPath[p] = true
+		prev = p
+	}
+	bombs := make(map[domain.Vec2d]bool, len(c.Bombs))
+	for _, b := range c.Bombs {
+		if !onPath[b] {
+			return fmt.Errorf("bomb %v is not on the path", b)
+		}
+		bombs[b] = true
+	}
+	u.path, u.bombs, u.progress = append([]domain.Vec2d(nil), c.Path...), bombs, 0
+	e.placeBomb(u) // бомба под ногами ставится сразу
+	return nil
+}
+
+func (e *Engine) placeBomb(u *unit) {
+	if !u.bombs[u.pos] || u.available == 0 || e.bombAt(u.pos) != nil {
+		return
+	}
+	delete(u.bombs, u.pos)
+	u.available--
+	e.stats.Bombs++
+	e.bombs = append(e.bombs, &bomb{pos: u.pos, owner: u, explodeAt: e.now + bombDelay, radius: e.radius})
+}
+
+// Advance двигает мир на d
+func (e *Engine) Advance(d time.Duration) {
+	for end := e.now + d; e.now < end; {
+		e.now += TickPeriod
+		e.tick()
+	}
+}
+
+func (e *Engine) tick() {
+	dt := TickPeriod.Seconds()
+	for _, u := range e.units {
+		if !u.alive || len(u.path) == 0 {
+			continue
+		}
+		u.progress += dt * float64(e.speed)
+		for u.progress >= 1 && len(u.path) > 0 {
+			u.progress--
+			next := u.path[0]
+			if !e.passable(next) {
+				u.path, u.progress = nil, 0 // бомба на дороге - путь сбрасывается
+				break
+			}
+			u.pos, u.path = next, u.path[1:]
+			e.placeBomb(u)
+		}
+	}
+	for _, w := range e.enemies {
+		e.walk(w, dt)
+	}
+	for _, m := range e.mobs {
+		if e.now >= m.wakeAt {
+			e.walk(m, dt)
+		}
+	}
+	e.explode()
+	e.collide()
+}
+
+// walk - шаг бродяги: держит направление, иногда меняет, в тупике выбирает новое
+func (e *Engine) walk(w *walker, dt float64) {
+	if !w.alive {
+		return
+	}
+	w.progress += dt * float64(w.speed)
+	for w.progress >= 1 {
+		w.progress--
+		next := domain.Vec2d{w.pos.X() + w.dir.X(), w.pos.Y() + w.dir.Y()}
+		if w.dir == (domain.Vec2d{}) || !e.passable(next) || e.rng.Intn(5) == 0 {
+			free := []domain.Vec2d{}
+			for _, d := range dirs {
+				if e.passable(domain.Vec2d{w.pos.X() + d.X(), w.pos.Y() + d.Y()}) {
+					free = append(free, d)
+				}
+			}
+			if len(free) == 0 {
+				return
+			}
+			w.dir = free[e.rng.Intn(len(free))]
+			next = domain.Vec2d{w.pos.X() + w.dir.X(), w.pos.Y() + w.dir.Y()}
+		}
+		w.pos = next
+	}
+}
+
+// explode подрывает бомбы с истекшим таймером и все, что они задели по цепочке
+func (e *Engine) explode() {
+	queue := []*bomb{}
+	for _, b := range e.bombs {
+		if b.explodeAt <= e.now {
+			queue = append(queue, b)
+		}
+	}
+	if len(queue) == 0 {
+		return
+	}
+	done := map[*bomb]bool{}
+	fire := map[domain.Vec2d]bool{}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		if done[b] {
+			continue
+		}
+		done[b] = true
+		fire[b.pos] = true
+		boxes := 0
+		for _, d := range dirs {
+			for i := 1; i <= b.radius; i++ {
+				p := domain.Vec2d{b.pos.X() + d.X()*i, b.pos.Y() + d.Y()*i}
+				if !e.valid(p) || e.walls[p] {
+					break
+				}
+				fire[p] = true
+				if e.obstacles[p] {
+					delete(e.obstacles, p)
+					boxes++
+					if boxes <= maxBoxPoints {
+						e.stats.Score += boxes
+					}
+					e.stats.Obstacles++
+					break
+				}
+				if other := e.bombAt(p); other != nil {
+					queue = append(queue, other) // задетая бомба взрывается досрочно
+					break
+				}
+			}
+		}
+	}
+	kept := e.bombs[:0]
+	for _, b := range e.bombs {
+		if done[b] {
+			b.owner.available++
+			continue
+		}
+		kept = append(kept, b)
+	}
+	e.bombs = kept
+
+	for _, u := range e.units {
+		if u.alive && fire[u.pos] && e.now >= u.safeUntil {
+			e.kill(u)
+		}
+	}
+	for _, w := range append(append([]*walker(nil), e.enemies...), e.mobs...) {
+		if w.alive && fire[w.pos] && e.now >= w.wakeAt {
+			w.alive = false
+			e.stats.Kills++
+			e.stats.Score += killPoints
+		}
+	}
+}
+
+// collide - юнит на одной клетке с проснувшимся мобом погибает
+func (e *Engine) collide() {
+	for _, m := range e.mobs {
+		if !m.alive || e.now < m.wakeAt {
+			continue
+		}
+		for _, u := range e.units {
+			if u.alive && u.pos == m.pos && e.now >= u.safeUntil {
+				e.kill(u)
+			}
+		}
+	}
+}
+
+func (e *Engine) kill(u *unit) {
+	u.alive, u.path = false, nil
+	e.stats.Deaths++
+	for _, other := range e.units {
+		if other.alive {
+			return
+		}
+	}
+	// Погибли все - возрождение в новой точке со штрафом
+	e.stats.Respawns++
+	e.stats.Score -= e.stats.Score * respawnFine / 100
+	spawn := e.freeCell()
+	e.clearAround(spawn)
+	for _, other := range e.units {
+		other.pos, other.alive, other.path, other.progress = spawn, true, nil, 0
+		other.safeUntil = e.now + respawnSafe
+	}
+}
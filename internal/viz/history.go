@@ -0,0 +1,97 @@
+package viz
+
+import (
+	"encoding/json"
+	"gorutin/internal/domain"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultHistory - сколько тиков помнит сервер по умолчанию (~6.5 минут при тике 650мс)
+const DefaultHistory = 600
+
+// historyEntry - один тик для перемотки: что бот видел и что решил
+type historyEntry struct {
+	N         int                        `json:"n"` // сквозной номер записи (тики бота повторяются от раунда к раунду)
+	Tick      int                        `json:"tick"`
+	At        time.Time                  `json:"at"`
+	State     *domain.GameState          `json:"state"`
+	Grid      [][]int                    `json:"grid"`
+	Boosters  json.RawMessage            `json:"boosters"`
+	Overlays  map[string]json.RawMessage `json:"overlays"`
+	Commands  *domain.PlayerCommand      `json:"commands"`
+	Decisions any                        `json:"decisions"`
+	Logs      []string                   `json:"logs"` // строки лога, добавленные за этот тик
+}
+
+// history - кольцевой буфер сериализованных тиков
+type history struct {
+	entries  [][]byte
+	next     int // номер следующей записи
+	logTotal int // logTotal сервера на момент прошлой записи
+}
+
+// span - номера первой и последней записи в буфере; ok=false - буфер пуст
+func (h *history) span() (first, last int, ok bool) {
+	if h.next == 0 || len(h.entries) == 0 {
+		return 0, 0, false
+	}
+	return max(0, h.next-len(h.entries)), h.next - 1, true
+}
+
+// RecordTick кладет текущий снимок (Update и оверлеи этого тика) в историю вместе с командами и решениями юнитов.
+// Зовется из одной горутины - той же, что зовет Update.
+func (s *Server) RecordTick(tick int, commands *domain.PlayerCommand, decisions any) {
+	if s.History <= 0 {
+		return
+	}
+	f := s.frame()
+	e := historyEntry{Tick: tick, At: time.Now(), State: f.state, Grid: f.grid, Boosters: f.boosters,
+		Overlays: f.overlays, Commands: commands, Decisions: decisions}
+
+	s.mu.Lock()
+	h := &s.history
+	if len(h.entries) != s.History {
+		h.entries, h.next = make([][]byte, s.History), 0
+	}
+	if n := f.logTotal - h.logTotal; n > 0 {
+		e.Logs = f.logs[max(0, len(f.logs)-n):]
+	}
+	h.logTotal = f.logTotal
+	e.N = h.next
+	s.mu.Unlock()
+
+	// Сериализуем без мьютекса: Update и обработчики не ждут
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	h.entries[e.N%len(h.entries)] = data
+	h.next = e.N + 1
+	s.mu.Unlock()
+	s.changed()
+}
+
+// handleHistory - запись истории с номером n: GET /api/history/{n}
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil {
+		http.Error(w, "bad entry number", http.StatusBadRequest)
+		return
+	}
+	s.mu.RLock()
+	first, last, ok := s.history.span()
+	var data []byte
+	if ok && n >= first && n <= last {
+		data = s.history.entries[n%len(s.history.entries)]
+	}
+	s.mu.RUnlock()
+	if data == nil {
+		http.Error(w, "entry is not in the history buffer", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -15,37 +15,85 @@ type Server struct {
 	mu       sync.RWMutex
 	state    *domain.GameState
 	grid     [][]int
-	boosters *domain.BoosterState
+	boosters json.RawMessage
 	logs     []string
+	logTotal int // сколько строк добавлено за все время: по нему поток понимает, какие строки новые
+	overlays map[string]json.RawMessage
+
+	Metrics http.Handler // отдается на /metrics, если задан до Start
+	History int          // сколько тиков хранить для перемотки (0 - не хранить)
+	history history
+
+	// Поток обновлений (stream.go): публикатор сравнивает состояние с уже отправленным
+	notify chan struct{}
+	pubMu  sync.Mutex
+	seq    int
+	model  model
+	subs   map[*subscriber]struct{}
 }
 
 func NewServer() *Server {
 	return &Server{
-		logs: make([]string, 0),
+		logs:     make([]string, 0),
+		overlays: make(map[string]json.RawMessage),
+		notify:   make(chan struct{}, 1),
+		subs:     make(map[*subscriber]struct{}),
+		History:  DefaultHistory,
 	}
 }
 
 func (s *Server) Start(addr string) {
 	http.HandleFunc("/", s.handleIndex)
 	http.HandleFunc("/api/state", s.handleState)
+	http.HandleFunc("/api/stream", s.handleStream)
+	http.HandleFunc("GET /api/history/{n}", s.handleHistory)
+	if s.Metrics != nil {
+		http.Handle("/metrics", s.Metrics)
+	}
+	go s.publish()
 	go http.ListenAndServe(addr, nil)
 }
 
+// Update публикует новый снимок. Сетку бот переписывает на месте каждый тик - берем копию.
 func (s *Server) Update(state *domain.GameState, grid [][]int, boosters *domain.BoosterState) {
+	gridCopy := make([][]int, len(grid))
+	for x := range grid {
+		gridCopy[x] = append([]int(nil), grid[x]...)
+	}
+	var boostersJSON json.RawMessage
+	if boosters != nil {
+		boostersJSON, _ = json.Marshal(boosters)
+	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.state = state
-	s.grid = grid
-	s.boosters = boosters
+	s.grid = gridCopy
+	s.boosters = boostersJSON
+	s.mu.Unlock()
+	s.changed()
+}
+
+// SetOverlay публикует дополнительный слой данных бота (треки врагов и т.п.) под именем name.
+// Данные сериализуются сразу: бот может менять их после вызова.
+func (s *Server) SetOverlay(name string, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.overlays[name] = raw
+	s.mu.Unlock()
+	s.changed()
 }
 
 func (s *Server) AddLog(msg string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.logs = append(s.logs, msg)
+	s.logTotal++
 	if len(s.logs) > 50 {
 		s.logs = s.logs[len(s.logs)-50:]
 	}
+	s.mu.Unlock()
+	s.changed()
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -53,20 +101,21 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 	w.Write(indexHTML)
 }
 
+// handleState - весь снимок разом (для скриптов); дашборд слушает /api/stream
 func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
+	f := s.frame()
 	response := struct {
-		State    *domain.GameState    `json:"state"`
-		Grid     [][]int              `json:"grid"`
-		Boosters *domain.BoosterState `json:"boosters"`
-		Logs     []string             `json:"logs"`
+		State    *domain.GameState          `json:"state"`
+		Grid     [][]int                    `json:"grid"`
+		Boosters json.RawMessage            `json:"boosters"`
+		Logs     []string                   `json:"logs"`
+		Overlays map[string]json.RawMessage `json:"overlays"`
 	}{
-		State:    s.state,
-		Grid:     s.grid,
-		Boosters: s.boosters,
-		Logs:     s.logs,
+		State:    f.state,
+		Grid:     f.grid,
+		Boosters: f.boosters,
+		Logs:     f.logs,
+		Overlays: f.overlays,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
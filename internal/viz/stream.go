@@ -0,0 +1,337 @@
+package viz
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorutin/internal/domain"
+	"net/http"
+	"time"
+)
+
+const (
+	publishDelay    = 20 * time.Millisecond // Update и SetOverlay за тик приходят пачкой - ждем всю
+	subscriberQueue = 16                    // сколько обновлений копим для медленного клиента до пересинхронизации
+	keepAlive       = 15 * time.Second
+)
+
+// model - то, что клиенты уже получили: с ним сравнивается каждое новое состояние
+type model struct {
+	meta        json.RawMessage                       // раунд, очки, размер карты
+	collections map[string]map[string]json.RawMessage // сущности по ID (или по клетке "x,y")
+	grid        [][]int
+	boosters    json.RawMessage
+	overlays    map[string]json.RawMessage
+	logTotal    int // сколько строк лога всего отправлено
+	history     *[2]int
+}
+
+// collectionDelta - изменившиеся и пропавшие сущности одной коллекции
+type collectionDelta struct {
+	Set map[string]json.RawMessage `json:"set,omitempty"`
+	Del []string                   `json:"del,omitempty"`
+}
+
+// delta - одно обновление потока. Full - полный снимок: клиент выбрасывает все, что знал.
+type delta struct {
+	Seq         int                         `json:"seq"`
+	Full        bool                        `json:"full,omitempty"`
+	Meta        json.RawMessage             `json:"meta,omitempty"`
+	Collections map[string]*collectionDelta `json:"collections,omitempty"`
+	Grid        [][]int                     `json:"grid,omitempty"`  // вся сетка: полный снимок или новый размер
+	Cells       [][3]int                    `json:"cells,omitempty"` // [x, y, тайл] изменившихся клеток
+	Boosters    json.RawMessage             `json:"boosters,omitempty"`
+	Overlays    map[string]json.RawMessage  `json:"overlays,omitempty"`
+	Logs        []string                    `json:"logs,omitempty"`    // новые строки (в полном снимке - все)
+	History     *[2]int                     `json:"history,omitempty"` // первая и последняя запись истории
+}
+
+func (d *delta) empty() bool {
+	return d.Meta == nil && len(d.Collections) == 0 && d.Grid == nil && len(d.Cells) == 0 &&
+		d.Boosters == nil && len(d.Overlays) == 0 && len(d.Logs) == 0 && d.History == nil
+}
+
+// subscriber - клиент потока. nil в очереди - знак пересинхронизации: клиент отстал и получит полный снимок.
+type subscriber struct {
+	queue chan []byte
+}
+
+// frame - то, что публикуется: копия под мьютексом сервера, чтобы сериализовать без него
+type frame struct {
+	state    *domain.GameState
+	grid     [][]int
+	boosters json.RawMessage
+	overlays map[string]json.RawMessage
+	logs     []string
+	logTotal int
+	history  *[2]int // nil - история пуста
+}
+
+func (s *Server) frame() frame {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f := frame{state: s.state, grid: s.grid, boosters: s.boosters, logs: s.logs, logTotal: s.logTotal,
+		overlays: make(map[string]json.RawMessage, len(s.overlays))}
+	if first, last, ok := s.history.span(); ok {
+		f.history = &[2]int{first, last}
+	}
+	for k, v := range s.overlays {
+		f.overlays[k] = v
+	}
+	return f
+}
+
+// changed будит публикатора
+func (s *Server) changed() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// publish рассылает подписчикам отличия от уже отправленного, пока сервер жив
+func (s *Server) publish() {
+	for range s.notify {
+		time.Sleep(publishDelay)
+		f := s.frame()
+
+		s.pubMu.Lock()
+		d := s.diff(f)
+		if !d.empty() {
+			s.seq++
+			d.Seq = s.seq
+			data, err := json.Marshal(d)
+			if err == nil {
+				for sub := range s.subs {
+					offerUpdate(sub, data)
+				}
+			}
+		}
+		s.pubMu.Unlock()
+	}
+}
+
+// offerUpdate кладет обновление в очередь клиента; если он не успевает - вместо очереди знак пересинхронизации
+func offerUpdate(sub *subscriber, data []byte) {
+	select {
+	case sub.queue <- data:
+		return
+	default:
+	}
+	for {
+		select {
+		case <-sub.queue:
+		default:
+			sub.queue <- nil
+			return
+		}
+	}
+}
+
+// diff сравнивает кадр с моделью, обновляет модель и возвращает разницу; вызывать под pubMu
+func (s *Server) diff(f frame) *delta {
+	m := &s.model
+	d := &delta{}
+
+	meta, collections := split(f.state)
+	if string(meta) != string(m.meta) {
+		d.Meta, m.meta = meta, meta
+	}
+	for name := range m.collections {
+		if _, ok := collections[name]; !ok {
+			collections[name] = nil // коллекция опустела - все ее сущности уходят в del
+		}
+	}
+	for name, items := range collections {
+		cd := &collectionDelta{}
+		old := m.collections[name]
+		for k, v := range items {
+			if string(old[k]) != string(v) {
+				if cd.Set == nil {
+					cd.Set = map[string]json.RawMessage{}
+				}
+				cd.Set[k] = v
+			}
+		}
+		for k := range old {
+			if _, ok := items[k]; !ok {
+				cd.Del = append(cd.Del, k)
+			}
+		}
+		if cd.Set != nil || cd.Del != nil {
+			if d.Collections == nil {
+				d.Collections = map[string]*collectionDelta{}
+			}
+			d.Collections[name] = cd
+		}
+	}
+	m.collections = collections
+
+	if !sameSize(f.grid, m.grid) {
+		d.Grid = f.grid
+	} else {
+		for x := range f.grid {
+			for y, v := range f.grid[x] {
+				if m.grid[x][y] != v {
+					d.Cells = append(d.Cells, [3]int{x, y, v})
+				}
+			}
+		}
+	}
+	m.grid = f.grid
+
+	if string(f.boosters) != string(m.boosters) {
+		d.Boosters, m.boosters = f.boosters, f.boosters
+	}
+	for k, v := range f.overlays {
+		if string(m.overlays[k]) != string(v) {
+			if d.Overlays == nil {
+				d.Overlays = map[string]json.RawMessage{}
+			}
+			d.Overlays[k] = v
+		}
+	}
+	m.overlays = f.overlays
+
+	if n := f.logTotal - m.logTotal; n > 0 {
+		d.Logs = f.logs[max(0, len(f.logs)-n):]
+	}
+	m.logTotal = f.logTotal
+
+	if f.history != nil && (m.history == nil || *f.history != *m.history) {
+		d.History = f.history
+	}
+	m.history = f.history
+	return d
+}
+
+// full - полный снимок того, что клиенты видят сейчас; вызывать под pubMu
+func (s *Server) full() ([]byte, error) {
+	m := &s.model
+	d := &delta{Seq: s.seq, Full: true, Meta: m.meta, Grid: m.grid, Boosters: m.boosters, Overlays: m.overlays,
+		History: m.history, Collections: make(map[string]*collectionDelta, len(m.collections))}
+	for name, items := range m.collections {
+		d.Collections[name] = &collectionDelta{Set: items}
+	}
+	s.mu.RLock()
+	d.Logs = append([]string(nil), s.logs...)
+	s.mu.RUnlock()
+	return json.Marshal(d)
+}
+
+// split раскладывает состояние на скаляры и коллекции сущностей с ключами
+func split(state *domain.GameState) (json.RawMessage, map[string]map[string]json.RawMessage) {
+	collections := map[string]map[string]json.RawMessage{}
+	if state == nil {
+		return nil, collections
+	}
+	meta, _ := json.Marshal(struct {
+		Round    string       `json:"round"`
+		RawScore int          `json:"raw_score"`
+		MapSize  domain.Vec2d `json:"map_size"`
+	}{state.Round, state.RawScore, state.MapSize})
+
+	add := func(name, key string, v any) {
+		if collections[name] == nil {
+			collections[name] = map[string]json.RawMessage{}
+		}
+		if data, err := json.Marshal(v); err == nil {
+			collections[name][key] = data
+		}
+	}
+	cell := func(p domain.Vec2d) string { return fmt.Sprintf("%d,%d", p.X(), p.Y()) }
+	for _, u := range state.MyUnits {
+		add("bombers", u.ID, u)
+	}
+	for _, e := range state.Enemies {
+		add("enemies", e.ID, e)
+	}
+	for _, m := range state.Mobs {
+		add("mobs", m.ID, m)
+	}
+	for _, b := range state.Arena.Bombs {
+		add("bombs", cell(b.Pos), b)
+	}
+	for _, p := range state.Arena.Obstacles {
+		add("obstacles", cell(p), p)
+	}
+	for _, p := range state.Arena.Walls {
+		add("walls", cell(p), p)
+	}
+	return meta, collections
+}
+
+func sameSize(a, b [][]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+	}
+	return a != nil
+}
+
+// handleStream - Server-Sent Events: сначала полный снимок, дальше только изменения с номером seq.
+// Клиент, пропустивший номер, переподключается и снова получает полный снимок.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	sub := &subscriber{queue: make(chan []byte, subscriberQueue)}
+	s.pubMu.Lock()
+	data, err := s.full()
+	s.subs[sub] = struct{}{}
+	s.pubMu.Unlock()
+	defer func() {
+		s.pubMu.Lock()
+		delete(s.subs, sub)
+		s.pubMu.Unlock()
+	}()
+	if err != nil {
+		return
+	}
+
+	send := func(data []byte) bool {
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+	if !send(data) {
+		return
+	}
+	ping := time.NewTicker(keepAlive)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case data := <-sub.queue:
+			if data == nil {
+				// Отстал - вместо пропущенных обновлений полный снимок
+				s.pubMu.Lock()
+				data, err = s.full()
+				s.pubMu.Unlock()
+				if err != nil {
+					return
+				}
+			}
+			if !send(data) {
+				return
+			}
+		}
+	}
+}